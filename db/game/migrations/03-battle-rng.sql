@@ -0,0 +1,8 @@
+-- ============================================
+-- BATTLE RNG
+-- ============================================
+
+-- Seed of the battle random generator. Every random roll of a turn (accuracy,
+-- critical hits, damage variance) is derived from this seed and the turn number,
+-- so a battle can be reproduced from its stored actions.
+ALTER TABLE battles ADD COLUMN rng_seed BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,19 @@
+-- ============================================
+-- BATTLE MOVE PP
+-- ============================================
+
+-- Remaining Power Points of every move of every team member during a battle
+CREATE TABLE battle_move_pp (
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 1 AND position <= 6),
+    move_id INTEGER REFERENCES moves(id) ON DELETE CASCADE,
+    remaining_pp INTEGER NOT NULL CHECK (remaining_pp >= 0),
+
+    PRIMARY KEY (battle_id, user_id, position, move_id)
+);
+
+-- Fallback move used when a pokemon has no PP left on any of its moves.
+-- It has no type so it is neutral against everything.
+INSERT INTO moves (name, type, power, accuracy, pp, effect_description) VALUES
+('Struggle', 'typeless', 50, 100, 1, 'Used only when no PP is left. Also hurts the user.');
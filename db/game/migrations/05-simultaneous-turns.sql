@@ -0,0 +1,21 @@
+-- ============================================
+-- SIMULTANEOUS TURNS
+-- ============================================
+
+-- Moves with higher priority act before slower moves regardless of speed
+ALTER TABLE moves ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+UPDATE moves SET priority = 1 WHERE name = 'Quick Attack';
+
+-- Action chosen by each player for a turn. The turn is resolved once both
+-- players submitted their action.
+CREATE TABLE battle_actions (
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    turn INTEGER NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    action_type VARCHAR(20) NOT NULL, -- 'attack', 'switch'
+    move_id INTEGER REFERENCES moves(id),
+    switch_position INTEGER CHECK (switch_position >= 1 AND switch_position <= 6),
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (battle_id, turn, user_id)
+);
@@ -0,0 +1,15 @@
+-- ============================================
+-- BATTLE RESULTS
+-- ============================================
+
+-- Stores battle results/history
+CREATE TABLE battle_results (
+    id SERIAL PRIMARY KEY,
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE UNIQUE,
+    winner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    loser_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    end_reason VARCHAR(50), -- 'all_fainted', 'surrender', 'disconnect'
+    total_turns INTEGER,
+    duration_seconds INTEGER,
+    completed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
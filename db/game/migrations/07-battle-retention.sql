@@ -0,0 +1,35 @@
+-- ============================================
+-- BATTLE RETENTION
+-- ============================================
+
+-- Finished battles are kept (status 'completed') so their result and replay
+-- data survive, pg_cron cleans them up once they are old enough.
+CREATE EXTENSION IF NOT EXISTS pg_cron;
+
+CREATE INDEX idx_battles_ended_at ON battles(ended_at);
+
+-- Battles still 'active' a day after they started were left behind (e.g. the
+-- server restarted mid battle), nobody can finish them anymore.
+SELECT cron.schedule(
+    'abandon-stale-battles',
+    '*/30 * * * *',
+    $$
+    UPDATE battles
+    SET status = 'abandoned',
+        ended_at = CURRENT_TIMESTAMP
+    WHERE status = 'active'
+      AND started_at < CURRENT_TIMESTAMP - INTERVAL '1 day'
+    $$
+);
+
+-- Finished battles are removed after 30 days, deleting a battle cascades to
+-- its result, team snapshots, PP and actions.
+SELECT cron.schedule(
+    'delete-old-battles',
+    '0 3 * * *',
+    $$
+    DELETE FROM battles
+    WHERE status IN ('completed', 'abandoned')
+      AND ended_at < CURRENT_TIMESTAMP - INTERVAL '30 days'
+    $$
+);
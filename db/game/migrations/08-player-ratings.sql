@@ -0,0 +1,15 @@
+-- ============================================
+-- PLAYER RATINGS
+-- ============================================
+
+-- Users only live for a session, ratings are kept by username so they
+-- persist across connections
+CREATE TABLE player_ratings (
+    username VARCHAR(50) PRIMARY KEY,
+    rating INTEGER NOT NULL DEFAULT 1500, -- Elo rating
+    games_played INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Only ranked battles update the ratings of their players
+ALTER TABLE battles ADD COLUMN ranked BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,28 @@
+-- ============================================
+-- BATTLE EVENTS
+-- ============================================
+
+-- Everything that happened in a battle, in order, so it can be replayed.
+-- Turn 0 holds the teams the battle started with, player1's team first.
+-- Users are deleted once they leave, so player_id is not a reference and
+-- the log survives them.
+CREATE TABLE battle_events (
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL, -- position of the event in the battle, starting at 1
+    turn INTEGER NOT NULL,
+    event_type VARCHAR(20) NOT NULL, -- 'team', 'action', 'switch', 'attack', 'miss', 'recoil', 'faint', 'end'
+    player_id UUID, -- owner of the pokemon the event is about, the winner on 'end'
+    position INTEGER,
+    species_id INTEGER REFERENCES pokemon_species(id), -- only on 'team'
+    action_type VARCHAR(20), -- only on 'action': 'attack', 'switch'
+    move_id INTEGER REFERENCES moves(id),
+    damage INTEGER,
+    remaining_hp INTEGER,
+    effectiveness VARCHAR(20),
+    critical_hit BOOLEAN NOT NULL DEFAULT FALSE,
+    end_reason VARCHAR(50), -- only on 'end'
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (battle_id, sequence)
+);
@@ -0,0 +1,21 @@
+-- ============================================
+-- STATUS CONDITIONS
+-- ============================================
+
+-- Non-volatile status a move may inflict on its target: 'burn', 'paralysis',
+-- 'poison', 'sleep' or 'freeze', with the chance in percent of inflicting it.
+ALTER TABLE moves ADD COLUMN status_effect VARCHAR(20);
+ALTER TABLE moves ADD COLUMN status_chance INTEGER NOT NULL DEFAULT 0;
+
+UPDATE moves SET status_effect = 'burn', status_chance = 10 WHERE name IN ('Ember', 'Flamethrower', 'Fire Blast');
+UPDATE moves SET status_effect = 'paralysis', status_chance = 30 WHERE name IN ('Body Slam', 'Thunder');
+UPDATE moves SET status_effect = 'freeze', status_chance = 10 WHERE name IN ('Ice Beam', 'Blizzard');
+UPDATE moves SET status_effect = 'poison', status_chance = 30 WHERE name IN ('Poison Sting', 'Sludge Bomb');
+
+-- Status of each battle pokemon, '' while healthy. status_turns counts the
+-- turns a sleeping pokemon has left to sleep.
+ALTER TABLE battle_pokemon ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE battle_pokemon ADD COLUMN status_turns INTEGER NOT NULL DEFAULT 0;
+
+-- Status inflicted or suffered on 'status', 'status_damage', 'cant_move' and 'cure' events
+ALTER TABLE battle_events ADD COLUMN status VARCHAR(20);
@@ -0,0 +1,67 @@
+-- ============================================
+-- MOVE EFFECTS
+-- ============================================
+
+-- Secondary effects of each move, run by the battle engine when the move hits.
+-- A move may have several, run in order of id. What magnitude means depends on the kind:
+--   'status'    inflicts status ('burn', 'paralysis', 'poison', 'sleep', 'freeze')
+--   'recoil'    the user loses magnitude % of the damage dealt
+--   'drain'     the user heals magnitude % of the damage dealt
+--   'flinch'    the target loses its move this turn if it has not moved yet
+--   'multi_hit' the move hits 2 to magnitude times
+--   'recharge'  the user can't move next turn
+CREATE TABLE move_effects (
+    id SERIAL PRIMARY KEY,
+    move_id INTEGER NOT NULL REFERENCES moves(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    target VARCHAR(10) NOT NULL DEFAULT 'target', -- 'target' or 'user'
+    chance INTEGER NOT NULL DEFAULT 100, -- chance in percent of the effect happening
+    magnitude INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20)
+);
+
+CREATE INDEX idx_move_effects_move ON move_effects(move_id);
+
+-- Status effects move over from the moves table
+INSERT INTO move_effects (move_id, kind, chance, status)
+SELECT id, 'status', status_chance, status_effect FROM moves WHERE status_effect IS NOT NULL;
+
+ALTER TABLE moves DROP COLUMN status_effect;
+ALTER TABLE moves DROP COLUMN status_chance;
+
+INSERT INTO move_effects (move_id, kind, target)
+SELECT id, 'recharge', 'user' FROM moves WHERE name = 'Hyper Beam';
+
+-- New moves, made possible by the effects above
+INSERT INTO moves (name, type, power, accuracy, pp, effect_description) VALUES
+('Double-Edge', 'normal', 120, 100, 15, 'A reckless tackle that also hurts the user.'),
+('Headbutt', 'normal', 70, 100, 15, 'A headbutt that may make the target flinch.'),
+('Fury Attack', 'normal', 15, 85, 20, 'Jabs the target 2 to 5 times in a row.'),
+('Double Kick', 'fighting', 30, 100, 30, 'Kicks the target twice in a row.'),
+('Giga Drain', 'grass', 75, 100, 10, 'Drains half the damage dealt to heal the user.');
+
+INSERT INTO move_effects (move_id, kind, target, chance, magnitude)
+SELECT id, 'recoil', 'user', 100, 33 FROM moves WHERE name = 'Double-Edge'
+UNION ALL
+SELECT id, 'flinch', 'target', 30, 0 FROM moves WHERE name = 'Headbutt'
+UNION ALL
+SELECT id, 'multi_hit', 'target', 100, 5 FROM moves WHERE name = 'Fury Attack'
+UNION ALL
+SELECT id, 'multi_hit', 'target', 100, 2 FROM moves WHERE name = 'Double Kick'
+UNION ALL
+SELECT id, 'drain', 'user', 100, 50 FROM moves WHERE name = 'Giga Drain';
+
+-- Swap a move of some species for the new ones, every species keeps four
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Giga Drain')
+WHERE pokemon_species_id = 3 AND move_id = (SELECT id FROM moves WHERE name = 'Vine Whip');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Headbutt')
+WHERE pokemon_species_id = 6 AND move_id = (SELECT id FROM moves WHERE name = 'Quick Attack');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Double Kick')
+WHERE pokemon_species_id = 7 AND move_id = (SELECT id FROM moves WHERE name = 'Tackle');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Double-Edge')
+WHERE pokemon_species_id = 8 AND move_id = (SELECT id FROM moves WHERE name = 'Wing Attack');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Fury Attack')
+WHERE pokemon_species_id = 9 AND move_id = (SELECT id FROM moves WHERE name = 'Wing Attack');
+
+-- Pokemon that used a recharge move sit out their next turn
+ALTER TABLE battle_pokemon ADD COLUMN recharging BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,40 @@
+-- ============================================
+-- STAT STAGES
+-- ============================================
+
+-- 'stat' effects raise the stat ('attack', 'defense' or 'speed') by magnitude stages,
+-- or lower it when magnitude is negative
+ALTER TABLE move_effects ADD COLUMN stat VARCHAR(20);
+
+-- Stage of each stat of a battle pokemon, from -6 to +6. Missing stats are at 0,
+-- and every stage is reset when the pokemon switches out.
+ALTER TABLE battle_pokemon ADD COLUMN stages JSONB NOT NULL DEFAULT '{}';
+
+-- Stat that changed on 'stat' events and by how many stages
+ALTER TABLE battle_events ADD COLUMN stat VARCHAR(20);
+ALTER TABLE battle_events ADD COLUMN stat_change INTEGER;
+
+-- Moves without power, they only change stats
+INSERT INTO moves (name, type, power, accuracy, pp, effect_description) VALUES
+('Growl', 'normal', 0, 100, 40, 'Growls cutely to lower the target''s attack.'),
+('Leer', 'normal', 0, 100, 30, 'Gives an intimidating leer that lowers the target''s defense.'),
+('Swords Dance', 'normal', 0, 100, 20, 'A frenetic dance that sharply raises the user''s attack.'),
+('Agility', 'psychic', 0, 100, 30, 'Relaxes the body to sharply raise the user''s speed.');
+
+INSERT INTO move_effects (move_id, kind, target, chance, magnitude, stat)
+SELECT id, 'stat', 'target', 100, -1, 'attack' FROM moves WHERE name = 'Growl'
+UNION ALL
+SELECT id, 'stat', 'target', 100, -1, 'defense' FROM moves WHERE name = 'Leer'
+UNION ALL
+SELECT id, 'stat', 'user', 100, 2, 'attack' FROM moves WHERE name = 'Swords Dance'
+UNION ALL
+SELECT id, 'stat', 'user', 100, 2, 'speed' FROM moves WHERE name = 'Agility';
+
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Growl')
+WHERE pokemon_species_id = 4 AND move_id = (SELECT id FROM moves WHERE name = 'Thunder Shock');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Agility')
+WHERE pokemon_species_id = 6 AND move_id = (SELECT id FROM moves WHERE name = 'Confusion');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Leer')
+WHERE pokemon_species_id = 8 AND move_id = (SELECT id FROM moves WHERE name = 'Surf');
+UPDATE pokemon_moves SET move_id = (SELECT id FROM moves WHERE name = 'Swords Dance')
+WHERE pokemon_species_id = 9 AND move_id = (SELECT id FROM moves WHERE name = 'Flamethrower');
@@ -0,0 +1,21 @@
+-- ============================================
+-- BATTLE RESULT PLAYERS
+-- ============================================
+
+-- Users are deleted once they leave, and bots right after their battle, so
+-- finished battles keep their players as plain ids (like battle_events does)
+-- instead of references that would be set to NULL.
+ALTER TABLE battles DROP CONSTRAINT battles_player1_id_fkey;
+ALTER TABLE battles DROP CONSTRAINT battles_player2_id_fkey;
+ALTER TABLE battles DROP CONSTRAINT battles_winner_id_fkey;
+
+ALTER TABLE battle_results DROP CONSTRAINT battle_results_winner_id_fkey;
+ALTER TABLE battle_results DROP CONSTRAINT battle_results_loser_id_fkey;
+
+-- Names the players had when the battle ended, the only trace of them left
+-- once their users are gone
+ALTER TABLE battle_results ADD COLUMN winner_username VARCHAR(50);
+ALTER TABLE battle_results ADD COLUMN loser_username VARCHAR(50);
+
+UPDATE battle_results r SET winner_username = u.username FROM users u WHERE u.id = r.winner_id;
+UPDATE battle_results r SET loser_username = u.username FROM users u WHERE u.id = r.loser_id;
@@ -21,7 +21,20 @@ CREATE TABLE moves (
     accuracy INTEGER NOT NULL, -- 0-100
     pp INTEGER NOT NULL, -- Power Points (how many times it can be used)
     effect_description TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    priority INTEGER NOT NULL DEFAULT 0 -- moves with higher priority act first
+);
+
+-- Secondary effects of each move, run in order of id when the move hits
+CREATE TABLE move_effects (
+    id SERIAL PRIMARY KEY,
+    move_id INTEGER NOT NULL REFERENCES moves(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- 'status', 'recoil', 'drain', 'flinch', 'multi_hit', 'recharge', 'stat'
+    target VARCHAR(10) NOT NULL DEFAULT 'target', -- 'target' or 'user'
+    chance INTEGER NOT NULL DEFAULT 100, -- chance in percent of the effect happening
+    magnitude INTEGER NOT NULL DEFAULT 0, -- % of damage for 'recoil' and 'drain', max hits for 'multi_hit', stages for 'stat'
+    status VARCHAR(20), -- for 'status': 'burn', 'paralysis', 'poison', 'sleep', 'freeze'
+    stat VARCHAR(20) -- for 'stat': 'attack', 'defense', 'speed'
 );
 
 CREATE TABLE pokemon_moves (
@@ -53,6 +66,14 @@ CREATE TABLE user_team (
     UNIQUE(user_id, position)
 );
 
+-- Rating of each player, kept by username since users only live for a session
+CREATE TABLE player_ratings (
+    username VARCHAR(50) PRIMARY KEY,
+    rating INTEGER NOT NULL DEFAULT 1500, -- Elo rating
+    games_played INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- ============================================
 -- BATTLES
 -- ============================================
@@ -60,32 +81,105 @@ CREATE TABLE user_team (
 -- Stores battle instances
 CREATE TABLE battles (
     id UUID PRIMARY KEY,
-    player1_id UUID REFERENCES users(id) ON DELETE SET NULL,
-    player2_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    player1_id UUID, -- not references so finished battles survive their users
+    player2_id UUID,
     status VARCHAR(20) DEFAULT 'active', -- 'active', 'completed', 'abandoned'
-    winner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    winner_id UUID,
     current_turn INTEGER DEFAULT 1,
     player1_active_pokemon_position INTEGER, -- which slot is currently active
     player2_active_pokemon_position INTEGER,
     started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     ended_at TIMESTAMP,
+    rng_seed BIGINT NOT NULL DEFAULT 0, -- seed of the battle random generator
+    ranked BOOLEAN NOT NULL DEFAULT FALSE, -- ranked battles update the ratings of their players
     
     CHECK (player1_id != player2_id)
 );
 
+-- Snapshot of each team taken when a battle starts, holds the per-battle state
+CREATE TABLE battle_pokemon (
+    id SERIAL PRIMARY KEY,
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    pokemon_species_id INTEGER REFERENCES pokemon_species(id),
+    position INTEGER NOT NULL CHECK (position >= 1 AND position <= 6),
+    current_hp INTEGER NOT NULL,
+    is_fainted BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT '', -- '' while healthy
+    status_turns INTEGER NOT NULL DEFAULT 0, -- turns left to sleep
+    recharging BOOLEAN NOT NULL DEFAULT FALSE, -- sits out its next turn
+    stages JSONB NOT NULL DEFAULT '{}', -- stage of each stat, -6 to +6
+    
+    UNIQUE(battle_id, user_id, position)
+);
+
 -- Stores battle results/history
 CREATE TABLE battle_results (
     id SERIAL PRIMARY KEY,
     battle_id UUID REFERENCES battles(id) ON DELETE CASCADE UNIQUE,
-    winner_id UUID REFERENCES users(id) ON DELETE SET NULL,
-    loser_id UUID REFERENCES users(id) ON DELETE SET NULL,
-    end_reason VARCHAR(50), -- 'all_fainted', 'surrender', 'disconnect'
+    winner_id UUID, -- not references so the result survives the users
+    loser_id UUID,
+    end_reason VARCHAR(50), -- 'all_fainted', 'surrender', 'disconnect', 'timeout'
     total_turns INTEGER,
     duration_seconds INTEGER,
-    completed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    completed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    winner_username VARCHAR(50),
+    loser_username VARCHAR(50)
+);
+
+-- Remaining Power Points of every move of every team member during a battle
+CREATE TABLE battle_move_pp (
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 1 AND position <= 6),
+    move_id INTEGER REFERENCES moves(id) ON DELETE CASCADE,
+    remaining_pp INTEGER NOT NULL CHECK (remaining_pp >= 0),
+
+    PRIMARY KEY (battle_id, user_id, position, move_id)
+);
+
+-- Action chosen by each player for a turn
+CREATE TABLE battle_actions (
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    turn INTEGER NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    action_type VARCHAR(20) NOT NULL, -- 'attack', 'switch'
+    move_id INTEGER REFERENCES moves(id),
+    switch_position INTEGER CHECK (switch_position >= 1 AND switch_position <= 6),
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (battle_id, turn, user_id)
+);
+
+-- Everything that happened in a battle, in order, so it can be replayed.
+-- Turn 0 holds the teams the battle started with, player1's team first.
+CREATE TABLE battle_events (
+    battle_id UUID REFERENCES battles(id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL, -- position of the event in the battle, starting at 1
+    turn INTEGER NOT NULL,
+    event_type VARCHAR(20) NOT NULL, -- 'team', 'action', 'switch', 'attack', 'miss', 'recoil', 'faint', 'status', 'status_damage', 'cant_move', 'cure', 'drain', 'move', 'stat', 'end'
+    player_id UUID, -- not a reference so the log survives the users, the winner on 'end'
+    position INTEGER,
+    species_id INTEGER REFERENCES pokemon_species(id), -- only on 'team'
+    action_type VARCHAR(20), -- only on 'action': 'attack', 'switch'
+    move_id INTEGER REFERENCES moves(id),
+    damage INTEGER,
+    remaining_hp INTEGER,
+    effectiveness VARCHAR(20),
+    critical_hit BOOLEAN NOT NULL DEFAULT FALSE,
+    end_reason VARCHAR(50), -- only on 'end'
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(20), -- on 'status', 'status_damage', 'cant_move' and 'cure'
+    stat VARCHAR(20), -- only on 'stat'
+    stat_change INTEGER, -- only on 'stat', negative when the stat fell
+
+    PRIMARY KEY (battle_id, sequence)
 );
 
 CREATE INDEX idx_users_status ON users(status);
 CREATE INDEX idx_battles_status ON battles(status);
 CREATE INDEX idx_battles_players ON battles(player1_id, player2_id);
-CREATE INDEX idx_matchmaking_queue_joined ON matchmaking_queue(joined_at);
\ No newline at end of file
+CREATE INDEX idx_battles_ended_at ON battles(ended_at);
+CREATE INDEX idx_matchmaking_queue_joined ON matchmaking_queue(joined_at);
+CREATE INDEX idx_move_effects_move ON move_effects(move_id);
\ No newline at end of file
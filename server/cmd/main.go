@@ -30,6 +30,7 @@ func main() {
 	DBConfig := config.LoadDBConfig()
 	loggingConfig := config.LoadLoggingConfig()
 	corsConfig := config.LoadCorsConfig()
+	gameConfig := config.LoadGameConfig()
 
 	// Config logger
 	utils.ConfigureLogger(&loggingConfig)
@@ -53,11 +54,12 @@ func main() {
 	r.Use(poke_mw.CreateCors(&corsConfig))
 
 	// ROUTES
-	api := newAPI(DBCli)
+	api := newAPI(DBCli, gameConfig)
 	r.Get("/health", api.checkHealth)
 	r.Get("/pokemon", api.checkHealth)
 	r.Get("/battle/stats", api.checkHealth)
 	r.Get("/battle", api.battle)
+	r.Get("/battle/{battleID}/replay", api.getReplay)
 
 	// Start server
 	log.Printf("Running on http %s", apiPort)
@@ -69,12 +71,13 @@ type api struct {
 	checkHealth http.HandlerFunc
 	getPokemons http.HandlerFunc
 	getStats    http.HandlerFunc
+	getReplay   http.HandlerFunc
 
 	// WS
 	battle http.HandlerFunc
 }
 
-func newAPI(dbCli *pgxpool.Pool) api {
+func newAPI(dbCli *pgxpool.Pool, gameConfig config.GameConfig) api {
 	validator := validator.New()
 	DbQueries := game_db.New(dbCli)
 
@@ -94,6 +97,7 @@ func newAPI(dbCli *pgxpool.Pool) api {
 
 	return api{
 		checkHealth: http_h.GetHealth,
-		battle:      ws_h.NewHandler(dbCli, validator, &userService, matchmakingService, &battleService),
+		getReplay:   http_h.GetReplay(&battleService),
+		battle:      ws_h.NewHandler(dbCli, validator, &userService, matchmakingService, &battleService, gameConfig),
 	}
 }
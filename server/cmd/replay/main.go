@@ -0,0 +1,85 @@
+// Command replay re-runs stored battles from their event log and checks the
+// battle engine still produces what was recorded.
+//
+//	go run ./cmd/replay <battle_id> [battle_id...]
+//
+// It exits with status 1 if any battle does not match its log.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/DanielRasho/PokeSocket/internal/config"
+	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s <battle_id> [battle_id...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load config
+	godotenv.Load()
+	DBConfig := config.LoadDBConfig()
+	loggingConfig := config.LoadLoggingConfig()
+	utils.ConfigureLogger(&loggingConfig)
+
+	ctx := context.Background()
+
+	DBCli, err := postgres_cli.NewPGClient(ctx, DBConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize Postgres client")
+		return
+	}
+	defer DBCli.Close()
+
+	battleService := battle_s.New(DBCli, game_db.New(DBCli))
+
+	failed := false
+	for _, arg := range flag.Args() {
+		var battleID pgtype.UUID
+		if err := battleID.Scan(arg); err != nil {
+			fmt.Printf("%s: invalid battle id\n", arg)
+			failed = true
+			continue
+		}
+
+		report, err := battleService.VerifyBattle(ctx, battleID)
+		if err != nil {
+			fmt.Printf("%s: %v\n", arg, err)
+			failed = true
+			continue
+		}
+
+		if len(report.Mismatches) == 0 {
+			fmt.Printf("%s: OK (%d turns, %d events)\n", arg, report.Turns, report.Events)
+			continue
+		}
+
+		failed = true
+		fmt.Printf("%s: %d mismatches\n", arg, len(report.Mismatches))
+		for _, mismatch := range report.Mismatches {
+			fmt.Printf("  turn %d, event %d\n    recorded: %s\n    replayed: %s\n",
+				mismatch.Turn, mismatch.Sequence, mismatch.Recorded, mismatch.Replayed)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
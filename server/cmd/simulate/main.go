@@ -0,0 +1,191 @@
+// Command simulate plays bots against each other, without a server, to see how
+// balanced the species and moves of the game data are.
+//
+//	go run ./cmd/simulate [-team1 1,4,7 -team2 2,5,8] [-n 1000] [-format csv|json]
+//
+// Without teams every species battles every other one, one on one. The report
+// has a row per matchup with win rates, average turns and damage per hit.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/DanielRasho/PokeSocket/internal/config"
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/joho/godotenv"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Output formats of the report
+const (
+	FORMAT_CSV  = "csv"
+	FORMAT_JSON = "json"
+)
+
+func main() {
+	team1 := flag.String("team1", "", "species ids of the first team, comma separated")
+	team2 := flag.String("team2", "", "species ids of the second team, comma separated")
+	battles := flag.Int("n", 1000, "battles per matchup")
+	bot1 := flag.String("bot1", engine.BOT_LEVEL_GREEDY, "policy of the first team: random, greedy or lookahead")
+	bot2 := flag.String("bot2", engine.BOT_LEVEL_GREEDY, "policy of the second team: random, greedy or lookahead")
+	seed := flag.Int64("seed", 0, "seed of the first battle, 0 picks a random one")
+	maxTurns := flag.Int("max-turns", 200, "battles longer than this are counted as draws")
+	workers := flag.Int("workers", runtime.NumCPU(), "battles played at the same time")
+	format := flag.String("format", FORMAT_CSV, "report format: csv or json")
+	flag.Parse()
+
+	// The report goes to stdout, logs stay out of its way
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	if *format != FORMAT_CSV && *format != FORMAT_JSON {
+		log.Fatal().Str("format", *format).Msg("Unknown report format")
+	}
+	if *battles <= 0 || *maxTurns <= 0 {
+		log.Fatal().Msg("-n and -max-turns must be positive")
+	}
+	if (*team1 == "") != (*team2 == "") {
+		log.Fatal().Msg("Set both -team1 and -team2, or neither to play every species against each other")
+	}
+	if *seed == 0 {
+		*seed = engine.NewBattleSeed()
+	}
+
+	// Load config
+	godotenv.Load()
+	DBConfig := config.LoadDBConfig()
+
+	ctx := context.Background()
+
+	DBCli, err := postgres_cli.NewPGClient(ctx, DBConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize Postgres client")
+		return
+	}
+	defer DBCli.Close()
+
+	// The game data is read once, every battle is played in memory
+	catalog, err := battle_s.New(DBCli, game_db.New(DBCli)).LoadCatalog(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load game data")
+		return
+	}
+	rules := &engine.Engine{Catalog: catalog}
+
+	matchups := roundRobin(catalog)
+	if *team1 != "" {
+		matchup := Matchup{}
+		if matchup.Team1, err = parseTeam(*team1); err != nil {
+			log.Fatal().Err(err).Msg("Invalid -team1")
+		}
+		if matchup.Team2, err = parseTeam(*team2); err != nil {
+			log.Fatal().Err(err).Msg("Invalid -team2")
+		}
+		matchups = []Matchup{matchup}
+	}
+
+	opts := Options{
+		Battles:  *battles,
+		Bot1:     *bot1,
+		Bot2:     *bot2,
+		Seed:     *seed,
+		MaxTurns: int32(*maxTurns),
+		Workers:  *workers,
+	}
+	log.Info().Int64("seed", opts.Seed).Int("matchups", len(matchups)).Int("battles", opts.Battles).Msg("Simulating")
+
+	results := make([]MatchupResult, 0, len(matchups))
+	for _, matchup := range matchups {
+		result, err := simulate(rules, matchup, opts)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Simulation failed")
+		}
+		results = append(results, result)
+	}
+
+	if *format == FORMAT_JSON {
+		err = writeJSON(os.Stdout, results)
+	} else {
+		err = writeCSV(os.Stdout, results)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to write report")
+	}
+}
+
+// parseTeam reads a comma separated list of species ids
+func parseTeam(value string) ([]int32, error) {
+	team := []int32{}
+	for _, field := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid species id %q", field)
+		}
+		team = append(team, int32(id))
+	}
+	return team, nil
+}
+
+func writeJSON(w io.Writer, results []MatchupResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+func writeCSV(w io.Writer, results []MatchupResult) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"team1", "team2", "bot1", "bot2", "battles", "team1_wins", "team2_wins", "draws",
+		"team1_win_rate", "avg_turns",
+		"team1_hits", "team1_damage_mean", "team1_damage_p10", "team1_damage_p50", "team1_damage_p90", "team1_damage_max",
+		"team2_hits", "team2_damage_mean", "team2_damage_p10", "team2_damage_p50", "team2_damage_p90", "team2_damage_max",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	damageColumns := func(stats DamageStats) []string {
+		return []string{
+			strconv.Itoa(stats.Hits),
+			strconv.FormatFloat(stats.Mean, 'f', 2, 64),
+			strconv.Itoa(int(stats.P10)),
+			strconv.Itoa(int(stats.P50)),
+			strconv.Itoa(int(stats.P90)),
+			strconv.Itoa(int(stats.Max)),
+		}
+	}
+	for _, result := range results {
+		row := []string{
+			teamLabel(result.Team1),
+			teamLabel(result.Team2),
+			result.Bot1,
+			result.Bot2,
+			strconv.Itoa(result.Battles),
+			strconv.Itoa(result.Team1Wins),
+			strconv.Itoa(result.Team2Wins),
+			strconv.Itoa(result.Draws),
+			strconv.FormatFloat(result.Team1WinRate, 'f', 4, 64),
+			strconv.FormatFloat(result.AvgTurns, 'f', 2, 64),
+		}
+		row = append(row, damageColumns(result.Team1Damage)...)
+		row = append(row, damageColumns(result.Team2Damage)...)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
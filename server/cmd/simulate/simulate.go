@@ -0,0 +1,286 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Player ids of the simulated battles, the engine only needs them to tell both sides apart
+var (
+	player1ID = pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	player2ID = pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+)
+
+// Matchup is a pair of teams, by species id, played against each other
+type Matchup struct {
+	Team1 []int32
+	Team2 []int32
+}
+
+// Options of a simulation run, shared by every matchup
+type Options struct {
+	Battles  int    // Battles played per matchup
+	Bot1     string // Policy of team1, see engine.BOT_LEVEL_*
+	Bot2     string // Policy of team2
+	Seed     int64  // Battle i of a matchup is played with Seed+i
+	MaxTurns int32  // Battles still going after this many turns are draws
+	Workers  int
+}
+
+// DamageStats describes the damage of every hit a team landed
+type DamageStats struct {
+	Hits int     `json:"hits"`
+	Mean float64 `json:"mean"`
+	P10  int32   `json:"p10"`
+	P50  int32   `json:"p50"`
+	P90  int32   `json:"p90"`
+	Max  int32   `json:"max"`
+}
+
+// MatchupResult is the outcome of every battle of a matchup
+type MatchupResult struct {
+	Team1        []string    `json:"team1"` // Species names
+	Team2        []string    `json:"team2"`
+	Bot1         string      `json:"bot1"`
+	Bot2         string      `json:"bot2"`
+	Battles      int         `json:"battles"`
+	Team1Wins    int         `json:"team1_wins"`
+	Team2Wins    int         `json:"team2_wins"`
+	Draws        int         `json:"draws"`
+	Team1WinRate float64     `json:"team1_win_rate"`
+	AvgTurns     float64     `json:"avg_turns"`
+	Team1Damage  DamageStats `json:"team1_damage"`
+	Team2Damage  DamageStats `json:"team2_damage"`
+}
+
+// battleOutcome is what a single simulated battle reports
+type battleOutcome struct {
+	Winner       int // 1 or 2, 0 for a draw
+	Turns        int32
+	Team1Damages []int32
+	Team2Damages []int32
+}
+
+// roundRobin returns every species against every other one, one on one
+func roundRobin(catalog *engine.Catalog) []Matchup {
+	ids := make([]int32, 0, len(catalog.Species))
+	for id := range catalog.Species {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	matchups := []Matchup{}
+	for i, id1 := range ids {
+		for _, id2 := range ids[i+1:] {
+			matchups = append(matchups, Matchup{Team1: []int32{id1}, Team2: []int32{id2}})
+		}
+	}
+	return matchups
+}
+
+// simulate plays every battle of a matchup across the workers. Each battle only depends
+// on its own seed, so a run gives the same result whatever the number of workers.
+func simulate(rules *engine.Engine, matchup Matchup, opts Options) (MatchupResult, error) {
+	outcomes := make([]battleOutcome, opts.Battles)
+	errs := make([]error, opts.Battles)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				outcomes[i], errs[i] = playBattle(rules, matchup, opts, opts.Seed+int64(i))
+			}
+		}()
+	}
+	for i := range opts.Battles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return MatchupResult{}, err
+		}
+	}
+	return summarize(rules.Catalog, matchup, opts, outcomes), nil
+}
+
+// playBattle plays a battle out between two bots
+func playBattle(rules *engine.Engine, matchup Matchup, opts Options, seed int64) (battleOutcome, error) {
+	outcome := battleOutcome{}
+
+	player1, err := newSide(rules.Catalog, player1ID, matchup.Team1)
+	if err != nil {
+		return outcome, err
+	}
+	player2, err := newSide(rules.Catalog, player2ID, matchup.Team2)
+	if err != nil {
+		return outcome, err
+	}
+	state := engine.BattleState{Seed: seed, Turn: 1, Player1: player1, Player2: player2}
+
+	// Turns start at 1, so the bots' own choices never share a random source with the battle
+	r := engine.SeededRoller(seed, 0)
+
+	for {
+		if winnerID, ended := state.Winner(); ended {
+			outcome.Winner = 2
+			if winnerID == player1ID {
+				outcome.Winner = 1
+			}
+			return outcome, nil
+		}
+		if outcome.Turns >= opts.MaxTurns {
+			return outcome, nil // Draw
+		}
+
+		// Fainted pokemon are replaced by their bot before the next turn is played
+		if state.Switching() {
+			state, err = replaceFainted(rules, state, opts, r)
+			if err != nil {
+				return outcome, err
+			}
+			continue
+		}
+
+		action1, err := rules.BotAction(state, player1ID, opts.Bot1, r)
+		if err != nil {
+			return outcome, err
+		}
+		action2, err := rules.BotAction(state, player2ID, opts.Bot2, r)
+		if err != nil {
+			return outcome, err
+		}
+
+		state, _, err = rules.Apply(state, action1)
+		if err != nil {
+			return outcome, err
+		}
+		var events []engine.Event
+		state, events, err = rules.Apply(state, action2)
+		if err != nil {
+			return outcome, err
+		}
+
+		outcome.Turns++
+		for _, event := range events {
+			if event.Type != engine.EVENT_ATTACK {
+				continue
+			}
+			if event.PlayerID == player1ID {
+				outcome.Team1Damages = append(outcome.Team1Damages, event.Damage)
+			} else {
+				outcome.Team2Damages = append(outcome.Team2Damages, event.Damage)
+			}
+		}
+	}
+}
+
+// replaceFainted lets each bot choose the pokemon sent out in place of its fainted one
+func replaceFainted(rules *engine.Engine, state engine.BattleState, opts Options, r engine.Roller) (engine.BattleState, error) {
+	levels := map[pgtype.UUID]string{player1ID: opts.Bot1, player2ID: opts.Bot2}
+	for _, playerID := range []pgtype.UUID{player1ID, player2ID} {
+		if !state.MustSwitch(playerID) {
+			continue
+		}
+		action, err := rules.BotAction(state, playerID, levels[playerID], r)
+		if err != nil {
+			return state, err
+		}
+		state, _, err = rules.Apply(state, action)
+		if err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// newSide builds a team at full health, sending out its first member
+func newSide(catalog *engine.Catalog, playerID pgtype.UUID, speciesIDs []int32) (engine.Side, error) {
+	side := engine.Side{PlayerID: playerID, ActivePos: 1}
+	for i, speciesID := range speciesIDs {
+		poke, err := catalog.NewPokemon(int32(i+1), speciesID)
+		if err != nil {
+			return side, err
+		}
+		side.Team = append(side.Team, poke)
+	}
+	return side, nil
+}
+
+func summarize(catalog *engine.Catalog, matchup Matchup, opts Options, outcomes []battleOutcome) MatchupResult {
+	result := MatchupResult{
+		Team1:   speciesNames(catalog, matchup.Team1),
+		Team2:   speciesNames(catalog, matchup.Team2),
+		Bot1:    opts.Bot1,
+		Bot2:    opts.Bot2,
+		Battles: len(outcomes),
+	}
+
+	turns := 0
+	team1Damages, team2Damages := []int32{}, []int32{}
+	for _, outcome := range outcomes {
+		switch outcome.Winner {
+		case 1:
+			result.Team1Wins++
+		case 2:
+			result.Team2Wins++
+		default:
+			result.Draws++
+		}
+		turns += int(outcome.Turns)
+		team1Damages = append(team1Damages, outcome.Team1Damages...)
+		team2Damages = append(team2Damages, outcome.Team2Damages...)
+	}
+
+	if len(outcomes) > 0 {
+		result.Team1WinRate = float64(result.Team1Wins) / float64(len(outcomes))
+		result.AvgTurns = float64(turns) / float64(len(outcomes))
+	}
+	result.Team1Damage = damageStats(team1Damages)
+	result.Team2Damage = damageStats(team2Damages)
+	return result
+}
+
+func damageStats(damages []int32) DamageStats {
+	stats := DamageStats{Hits: len(damages)}
+	if len(damages) == 0 {
+		return stats
+	}
+	slices.Sort(damages)
+
+	total := 0
+	for _, damage := range damages {
+		total += int(damage)
+	}
+	percentile := func(p int) int32 {
+		return damages[(len(damages)-1)*p/100]
+	}
+	stats.Mean = float64(total) / float64(len(damages))
+	stats.P10 = percentile(10)
+	stats.P50 = percentile(50)
+	stats.P90 = percentile(90)
+	stats.Max = damages[len(damages)-1]
+	return stats
+}
+
+func speciesNames(catalog *engine.Catalog, speciesIDs []int32) []string {
+	names := make([]string, len(speciesIDs))
+	for i, id := range speciesIDs {
+		names[i] = catalog.Species[id].Name
+	}
+	return names
+}
+
+// teamLabel writes a team on a single CSV cell
+func teamLabel(names []string) string {
+	return strings.Join(names, "+")
+}
@@ -20,13 +20,9 @@ FROM pokemon_species
 WHERE id = @id;
 
 -- name: CreateBattle :one
-INSERT INTO battles (id, player1_id, player2_id, status, player1_active_pokemon_position, player2_active_pokemon_position)
-VALUES (@id, @player1_id, @player2_id, 'active', 1, 1)
-RETURNING id, player1_id, player2_id, status, started_at;
-
--- name: DeleteBattle :exec
-DELETE FROM battles
-WHERE id = @id;
+INSERT INTO battles (id, player1_id, player2_id, status, player1_active_pokemon_position, player2_active_pokemon_position, rng_seed, ranked)
+VALUES (@id, @player1_id, @player2_id, 'active', 1, 1, @rng_seed, @ranked)
+RETURNING id, player1_id, player2_id, status, started_at, rng_seed, ranked;
 
 -- name: GetUserTeam :many
 SELECT id, user_id, pokemon_species_id, position, current_hp, is_active, is_fainted
@@ -34,16 +30,61 @@ FROM user_team
 WHERE user_id = @user_id
 ORDER BY position;
 
+-- name: EndBattle :one
+UPDATE battles
+SET status = 'completed',
+    winner_id = @winner_id,
+    ended_at = CURRENT_TIMESTAMP
+WHERE id = @id
+RETURNING ranked;
+
+-- name: InsertBattleResult :exec
+INSERT INTO battle_results (battle_id, winner_id, winner_username, loser_id, loser_username, end_reason, total_turns, duration_seconds)
+SELECT b.id, @winner_id, w.username, @loser_id, l.username, @end_reason, b.current_turn - 1, EXTRACT(EPOCH FROM (b.ended_at - b.started_at))::INTEGER
+FROM battles b
+LEFT JOIN users w ON w.id = @winner_id
+LEFT JOIN users l ON l.id = @loser_id
+WHERE b.id = @battle_id;
+
 -- name: GetBattle :one
-SELECT id, player1_id, player2_id, status, current_turn, player1_active_pokemon_position, player2_active_pokemon_position
+SELECT id, player1_id, player2_id, status, current_turn, player1_active_pokemon_position, player2_active_pokemon_position, rng_seed
 FROM battles
 WHERE id = @id;
 
--- name: UpdatePokemonHP :exec
-UPDATE user_team
+-- name: SnapshotBattleTeam :exec
+INSERT INTO battle_pokemon (battle_id, user_id, pokemon_species_id, position, current_hp, is_fainted)
+SELECT @battle_id, ut.user_id, ut.pokemon_species_id, ut.position, ps.base_hp, false
+FROM user_team ut
+JOIN pokemon_species ps ON ps.id = ut.pokemon_species_id
+WHERE ut.user_id = @user_id;
+
+-- name: GetBattleTeam :many
+SELECT id, battle_id, user_id, pokemon_species_id, position, current_hp, is_fainted, status, status_turns, recharging, stages
+FROM battle_pokemon
+WHERE battle_id = @battle_id AND user_id = @user_id
+ORDER BY position;
+
+-- name: UpdateBattlePokemonHP :exec
+UPDATE battle_pokemon
 SET current_hp = @current_hp,
     is_fainted = CASE WHEN @current_hp <= 0 THEN true ELSE is_fainted END
-WHERE user_id = @user_id AND position = @position;
+WHERE battle_id = @battle_id AND user_id = @user_id AND position = @position;
+
+-- name: UpdateBattlePokemonStatus :exec
+UPDATE battle_pokemon
+SET status = @status,
+    status_turns = @status_turns
+WHERE battle_id = @battle_id AND user_id = @user_id AND position = @position;
+
+-- name: UpdateBattlePokemonRecharging :exec
+UPDATE battle_pokemon
+SET recharging = @recharging
+WHERE battle_id = @battle_id AND user_id = @user_id AND position = @position;
+
+-- name: UpdateBattlePokemonStages :exec
+UPDATE battle_pokemon
+SET stages = @stages
+WHERE battle_id = @battle_id AND user_id = @user_id AND position = @position;
 
 -- name: UpdateBattleTurn :exec
 UPDATE battles
@@ -58,4 +99,97 @@ WHERE id = @id;
 -- name: UpdatePlayer2ActivePokemon :exec
 UPDATE battles
 SET player2_active_pokemon_position = @player2_active_pokemon_position
-WHERE id = @id;
\ No newline at end of file
+WHERE id = @id;
+
+-- name: InitBattleMovePP :exec
+INSERT INTO battle_move_pp (battle_id, user_id, position, move_id, remaining_pp)
+SELECT bp.battle_id, bp.user_id, bp.position, m.id, m.pp
+FROM battle_pokemon bp
+JOIN pokemon_moves pm ON pm.pokemon_species_id = bp.pokemon_species_id
+JOIN moves m ON m.id = pm.move_id
+WHERE bp.battle_id = @battle_id AND bp.user_id = @user_id;
+
+-- name: GetBattleMovePP :many
+SELECT bmp.user_id, bmp.position, bmp.move_id, m.name, bmp.remaining_pp, m.pp AS max_pp
+FROM battle_move_pp bmp
+JOIN moves m ON m.id = bmp.move_id
+WHERE bmp.battle_id = @battle_id
+ORDER BY bmp.user_id, bmp.position, bmp.move_id;
+
+-- name: UseMovePP :one
+UPDATE battle_move_pp
+SET remaining_pp = remaining_pp - 1
+WHERE battle_id = @battle_id AND user_id = @user_id AND position = @position AND move_id = @move_id AND remaining_pp > 0
+RETURNING remaining_pp;
+
+-- name: LockBattle :one
+SELECT id, player1_id, player2_id, status, current_turn, player1_active_pokemon_position, player2_active_pokemon_position, rng_seed
+FROM battles
+WHERE id = @id
+FOR UPDATE;
+
+-- name: InsertBattleAction :exec
+INSERT INTO battle_actions (battle_id, turn, user_id, action_type, move_id, switch_position)
+VALUES (@battle_id, @turn, @user_id, @action_type, @move_id, @switch_position);
+
+-- name: GetTurnActions :many
+SELECT battle_id, turn, user_id, action_type, move_id, switch_position, submitted_at
+FROM battle_actions
+WHERE battle_id = @battle_id AND turn = @turn
+ORDER BY submitted_at;
+
+-- name: GetPlayerRating :one
+SELECT u.username, r.rating
+FROM users u
+LEFT JOIN player_ratings r ON r.username = u.username
+WHERE u.id = @user_id;
+
+-- name: SavePlayerRating :exec
+INSERT INTO player_ratings (username, rating, games_played, updated_at)
+VALUES (@username, @rating, 1, CURRENT_TIMESTAMP)
+ON CONFLICT (username) DO UPDATE
+SET rating = EXCLUDED.rating,
+    games_played = player_ratings.games_played + 1,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListPokemonSpeciesIDs :many
+SELECT id
+FROM pokemon_species
+ORDER BY id;
+
+-- name: InsertBattleEvent :exec
+INSERT INTO battle_events (battle_id, sequence, turn, event_type, player_id, position, species_id, action_type, move_id, damage, remaining_hp, effectiveness, critical_hit, end_reason, message, status, stat, stat_change)
+SELECT @battle_id, COALESCE(MAX(sequence), 0) + 1, @turn, @event_type, @player_id, @position, @species_id, @action_type, @move_id, @damage, @remaining_hp, @effectiveness, @critical_hit, @end_reason, @message, @status, @stat, @stat_change
+FROM battle_events
+WHERE battle_id = @battle_id;
+
+-- name: GetBattleEvents :many
+SELECT battle_id, sequence, turn, event_type, player_id, position, species_id, action_type, move_id, damage, remaining_hp, effectiveness, critical_hit, end_reason, message, created_at, status, stat, stat_change
+FROM battle_events
+WHERE battle_id = @battle_id
+ORDER BY sequence;
+
+-- name: GetBattleReplay :one
+SELECT id, status, ranked, rng_seed, current_turn, started_at, ended_at
+FROM battles
+WHERE id = @id;
+
+-- name: ListPokemonSpecies :many
+SELECT id, name, base_hp, base_attack, base_defense, base_speed, type1, type2
+FROM pokemon_species
+ORDER BY id;
+
+-- name: ListMoves :many
+SELECT id, name, type, power, accuracy, pp, priority
+FROM moves
+ORDER BY id;
+
+-- name: ListMoveEffects :many
+SELECT id, move_id, kind, target, chance, magnitude, status, stat
+FROM move_effects
+ORDER BY move_id, id;
+
+-- name: ListSpeciesMoves :many
+SELECT pokemon_species_id, move_id
+FROM pokemon_moves
+ORDER BY pokemon_species_id, move_id;
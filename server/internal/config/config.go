@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -20,6 +21,21 @@ type CorsConfig struct {
 	AllowedHeaders      []string
 }
 
+// What happens to a player that does not choose an action before the turn clock runs out
+const (
+	TURN_TIMEOUT_DEFAULT_ACTION = "default" // Their first move with PP left is used
+	TURN_TIMEOUT_FORFEIT        = "forfeit" // They lose the battle
+)
+
+type GameConfig struct {
+	ReconnectGracePeriod time.Duration // How long a dropped player is waited for before they lose their battle
+	TurnTimeout          time.Duration // Time players have to choose their action each turn, 0 disables the clock
+	TurnClockTick        time.Duration // How often players are told the remaining time, 0 disables the ticks
+	TurnTimeoutAction    string        // TURN_TIMEOUT_DEFAULT_ACTION or TURN_TIMEOUT_FORFEIT
+	RoomTimeout          time.Duration // How long a private room waits for the invited player
+	QueueMaxWait         time.Duration // How long a player waits in a matchmaking queue, 0 waits forever
+}
+
 func LoadRunningModeConfig() string {
 	return mustGetEnv("API_PORT")
 }
@@ -44,6 +60,18 @@ func LoadCorsConfig() CorsConfig {
 	}
 }
 
+func LoadGameConfig() GameConfig {
+	return GameConfig{
+		ReconnectGracePeriod: getEnvAsSecondsOrDefault("RECONNECT_GRACE_SECONDS", 30*time.Second),
+		TurnTimeout:          getEnvAsSecondsOrDefault("TURN_TIMEOUT_SECONDS", 60*time.Second),
+		TurnClockTick:        getEnvAsSecondsOrDefault("TURN_TICK_SECONDS", 10*time.Second),
+		TurnTimeoutAction: getEnvAsEnumOrDefault("TURN_TIMEOUT_ACTION",
+			[]string{TURN_TIMEOUT_DEFAULT_ACTION, TURN_TIMEOUT_FORFEIT}, TURN_TIMEOUT_DEFAULT_ACTION),
+		RoomTimeout:  getEnvAsSecondsOrDefault("ROOM_TIMEOUT_SECONDS", 5*time.Minute),
+		QueueMaxWait: getEnvAsSecondsOrDefault("QUEUE_MAX_WAIT_SECONDS", 5*time.Minute),
+	}
+}
+
 // mustGetEnv retrieves the value of the given environment variable
 // or exits with a fatal error if the variable is not set.
 func mustGetEnv(key string) string {
@@ -86,6 +114,15 @@ func mustGetEnvAsEnum(key string, enumValues []string) string {
 	return ""
 }
 
+// getEnvAsEnumOrDefault reads the given environment variable, returning def if
+// it is not set. Exits with a fatal error if the value is not one of enumValues.
+func getEnvAsEnumOrDefault(key string, enumValues []string, def string) string {
+	if _, exists := os.LookupEnv(key); !exists {
+		return def
+	}
+	return mustGetEnvAsEnum(key, enumValues)
+}
+
 func getEnvOrDefault(key, def string) string {
 	value, exist := os.LookupEnv(key)
 	if !exist {
@@ -93,3 +130,13 @@ func getEnvOrDefault(key, def string) string {
 	}
 	return value
 }
+
+// getEnvAsSecondsOrDefault reads the given environment variable as a number of
+// seconds, returning def if it is not set. Exits with a fatal error if the
+// value is not a valid integer.
+func getEnvAsSecondsOrDefault(key string, def time.Duration) time.Duration {
+	if _, exists := os.LookupEnv(key); !exists {
+		return def
+	}
+	return time.Duration(mustGetEnvAsInt(key)) * time.Second
+}
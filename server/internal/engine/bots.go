@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Difficulty levels of a bot opponent
+const (
+	BOT_LEVEL_RANDOM    = "random"    // Uses any move it has PP for
+	BOT_LEVEL_GREEDY    = "greedy"    // Uses the move expected to deal the most damage right now
+	BOT_LEVEL_LOOKAHEAD = "lookahead" // Plays the next turns out, switches included, assuming the best replies
+)
+
+// How many turns the lookahead bot plays out before judging the battle
+const BOT_LOOKAHEAD_DEPTH = 2
+
+// Damage roll the bots expect, halfway between DAMAGE_ROLL_MIN and 100
+const BOT_EXPECTED_ROLL = (DAMAGE_ROLL_MIN + 100) / 2.0 / 100
+
+// BotAction chooses the action of a bot for the current turn. r is only used by the random
+// level. The bot never looks at the action its opponent already chose.
+func (e *Engine) BotAction(state BattleState, botID pgtype.UUID, level string, r Roller) (Action, error) {
+	state = state.Clone()
+	state.Pending = nil
+	if _, _, err := state.Sides(botID); err != nil {
+		return Action{}, err
+	}
+	if state.Switching() && !state.MustSwitch(botID) {
+		return Action{}, fmt.Errorf("waiting for the opponent to replace its fainted pokemon")
+	}
+
+	switch level {
+	case BOT_LEVEL_RANDOM:
+		return e.randomAction(state, botID, r), nil
+	case BOT_LEVEL_GREEDY:
+		return e.greedyAction(state, botID), nil
+	case BOT_LEVEL_LOOKAHEAD:
+		return e.lookaheadAction(state, botID), nil
+	default:
+		return Action{}, fmt.Errorf("unknown bot level %q", level)
+	}
+}
+
+// ExpectedDamage is the damage a move deals on average, misses included
+func (e *Engine) ExpectedDamage(attacker, defender *Pokemon, move Move) float64 {
+	damage, _ := e.damage(attacker, defender, move, BOT_EXPECTED_ROLL)
+	return float64(damage) * expectedHits(move) * float64(move.Accuracy) / 100
+}
+
+// usableMoves returns the moves of the active pokemon with PP left. When there are none
+// the first move is returned, Validate replaces it with Struggle.
+func usableMoves(side *Side) []MoveSlot {
+	active := side.Active()
+	if active == nil {
+		return nil
+	}
+	moves := []MoveSlot{}
+	for _, move := range active.Moves {
+		if move.PP > 0 {
+			moves = append(moves, move)
+		}
+	}
+	if len(moves) == 0 && len(active.Moves) > 0 {
+		moves = append(moves, active.Moves[0])
+	}
+	return moves
+}
+
+// Options returns every action the player can choose this turn. While a fainted pokemon
+// is waiting to be replaced its player can only switch, and the opponent has no options.
+// A pokemon that must recharge can't be switched out, its move is skipped anyway.
+func Options(state BattleState, playerID pgtype.UUID) []Action {
+	side, _, err := state.Sides(playerID)
+	if err != nil {
+		return nil
+	}
+	options := []Action{}
+	forced := side.mustSwitch()
+	if state.Switching() && !forced {
+		return options
+	}
+	if !forced {
+		for _, move := range usableMoves(side) {
+			options = append(options, Action{PlayerID: playerID, Type: ACTION_ATTACK, MoveID: move.MoveID})
+		}
+	}
+	if !forced && side.Active() != nil && side.Active().Recharging {
+		return options
+	}
+	for _, poke := range side.Team {
+		if poke.Position != side.ActivePos && !poke.Fainted {
+			options = append(options, Action{PlayerID: playerID, Type: ACTION_SWITCH, Position: poke.Position})
+		}
+	}
+	return options
+}
+
+func (e *Engine) randomAction(state BattleState, botID pgtype.UUID, r Roller) Action {
+	side, _, _ := state.Sides(botID)
+	if side.mustSwitch() {
+		options := Options(state, botID)
+		return options[r.IntN(len(options))]
+	}
+	moves := usableMoves(side)
+	if len(moves) == 0 {
+		return Action{PlayerID: botID, Type: ACTION_ATTACK}
+	}
+	return Action{PlayerID: botID, Type: ACTION_ATTACK, MoveID: moves[r.IntN(len(moves))].MoveID}
+}
+
+func (e *Engine) greedyAction(state BattleState, botID pgtype.UUID) Action {
+	side, foe, _ := state.Sides(botID)
+	if side.mustSwitch() {
+		return e.greedySwitch(state, botID)
+	}
+	best := Action{PlayerID: botID, Type: ACTION_ATTACK}
+	bestDamage := -1.0
+	for _, move := range usableMoves(side) {
+		damage := 0.0
+		if foe.Active() != nil {
+			damage = e.ExpectedDamage(side.Active(), foe.Active(), e.Catalog.Moves[move.MoveID])
+		}
+		if damage > bestDamage {
+			best.MoveID = move.MoveID
+			bestDamage = damage
+		}
+	}
+	return best
+}
+
+// greedySwitch replaces a fainted pokemon with the one whose best move is expected
+// to deal the most damage to the opponent's active pokemon
+func (e *Engine) greedySwitch(state BattleState, botID pgtype.UUID) Action {
+	_, foe, _ := state.Sides(botID)
+	options := Options(state, botID)
+	best := options[0]
+	bestDamage := -1.0
+	for _, option := range options {
+		next := state.Clone()
+		side, _, _ := next.Sides(botID)
+		side.ActivePos = option.Position
+		for _, move := range usableMoves(side) {
+			damage := e.ExpectedDamage(side.Active(), foe.Active(), e.Catalog.Moves[move.MoveID])
+			if damage > bestDamage {
+				best, bestDamage = option, damage
+			}
+		}
+	}
+	return best
+}
+
+// lookaheadAction chooses the action with the best outcome after BOT_LOOKAHEAD_DEPTH turns,
+// assuming the opponent always answers with the reply that is worst for the bot.
+// Turns are played out by the engine itself, with a MedianRoller instead of the battle's luck.
+func (e *Engine) lookaheadAction(state BattleState, botID pgtype.UUID) Action {
+	sim := *e
+	sim.NewRoller = func(int64, int32) Roller { return MedianRoller{} }
+
+	_, foe, _ := state.Sides(botID)
+	foeID := foe.PlayerID
+
+	options := Options(state, botID)
+	if len(options) == 0 {
+		return Action{PlayerID: botID, Type: ACTION_ATTACK}
+	}
+	best := options[0]
+	bestScore := math.Inf(-1)
+	for _, option := range options {
+		score := sim.worstReply(state, botID, foeID, option, BOT_LOOKAHEAD_DEPTH)
+		if score > bestScore {
+			best, bestScore = option, score
+		}
+	}
+	return best
+}
+
+// worstReply returns the score of the bot choosing action, against the opponent's best reply
+func (e *Engine) worstReply(state BattleState, botID, foeID pgtype.UUID, action Action, depth int) float64 {
+	// Replacing a fainted pokemon takes no turn, the opponent replies once the battle goes on
+	if state.MustSwitch(botID) {
+		next, _, err := e.Apply(state, action)
+		if err != nil {
+			return math.Inf(-1)
+		}
+		return e.minimax(next, botID, foeID, depth)
+	}
+
+	worst := math.Inf(1)
+	for _, reply := range Options(state, foeID) {
+		next, ok := e.playTurn(state, action, reply)
+		if !ok {
+			continue
+		}
+		worst = math.Min(worst, e.minimax(next, botID, foeID, depth-1))
+	}
+	if math.IsInf(worst, 1) {
+		return math.Inf(-1)
+	}
+	return worst
+}
+
+func (e *Engine) minimax(state BattleState, botID, foeID pgtype.UUID, depth int) float64 {
+	if _, ended := state.Winner(); depth == 0 || ended {
+		return e.evaluate(state, botID)
+	}
+
+	// The opponent replaces its fainted pokemon with the one that is worst for the bot
+	if state.MustSwitch(foeID) && !state.MustSwitch(botID) {
+		worst := math.Inf(1)
+		for _, reply := range Options(state, foeID) {
+			next, _, err := e.Apply(state, reply)
+			if err != nil {
+				continue
+			}
+			worst = math.Min(worst, e.minimax(next, botID, foeID, depth))
+		}
+		return worst
+	}
+
+	best := math.Inf(-1)
+	for _, option := range Options(state, botID) {
+		best = math.Max(best, e.worstReply(state, botID, foeID, option, depth))
+	}
+	if math.IsInf(best, -1) {
+		return e.evaluate(state, botID)
+	}
+	return best
+}
+
+// playTurn resolves a turn where both players chose their action, false if either is not valid
+func (e *Engine) playTurn(state BattleState, action, reply Action) (BattleState, bool) {
+	next, _, err := e.Apply(state, action)
+	if err != nil {
+		return state, false
+	}
+	next, _, err = e.Apply(next, reply)
+	if err != nil {
+		return state, false
+	}
+	return next, true
+}
+
+// evaluate scores a battle from the side of the bot: the share of HP it has left
+// against the opponent's, with every pokemon still standing worth a bonus
+func (e *Engine) evaluate(state BattleState, botID pgtype.UUID) float64 {
+	score := func(side *Side) float64 {
+		total := 0.0
+		for _, poke := range side.Team {
+			if !poke.Fainted {
+				total += 1 + float64(poke.HP)/float64(max(e.Catalog.Species[poke.SpeciesID].BaseHP, 1))
+			}
+		}
+		return total
+	}
+	bot, foe, _ := state.Sides(botID)
+	return score(bot) - score(foe)
+}
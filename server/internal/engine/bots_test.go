@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"testing"
+)
+
+// faint knocks out the active pokemon of a side, leaving its player to replace it
+func faint(side *Side) {
+	active := side.Active()
+	active.HP = 0
+	active.Fainted = true
+}
+
+func TestBotAction(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		roll  int
+		want  Action
+	}{
+		{"random uses the rolled move", BOT_LEVEL_RANDOM, 1, attack(player2, quickAttack)},
+		{"random rolls among every move", BOT_LEVEL_RANDOM, 99, attack(player2, struggle)},
+		{"greedy uses the strongest move", BOT_LEVEL_GREEDY, 0, attack(player2, knockOut)},
+		{"lookahead knocks out right away", BOT_LEVEL_LOOKAHEAD, 0, attack(player2, knockOut)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(rolls(99))
+			state := newBattle(t, e, []int32{SLUGGISH}, []int32{SWIFT, BLAZE})
+
+			action, err := e.BotAction(state, player2, tt.level, rolls(tt.roll))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, action)
+			}
+			if _, err := e.Validate(state, action); err != nil {
+				t.Fatalf("the bot chose an invalid action: %v", err)
+			}
+		})
+	}
+}
+
+func TestGreedyBotSkipsMovesOutOfPP(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	drainPP(&state.Player2, knockOut, struggle)
+
+	action, err := e.BotAction(state, player2, BOT_LEVEL_GREEDY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tackle and Quick Attack deal the same, Wild Swing misses half the time
+	if action != attack(player2, tackle) {
+		t.Fatalf("expected Tackle, got move %d", action.MoveID)
+	}
+}
+
+func TestBotReplacesFainted(t *testing.T) {
+	for _, level := range []string{BOT_LEVEL_RANDOM, BOT_LEVEL_GREEDY, BOT_LEVEL_LOOKAHEAD} {
+		t.Run(level, func(t *testing.T) {
+			e := testEngine(rolls(99))
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH, BLAZE, VOLT})
+			faint(&state.Player2)
+
+			action, err := e.BotAction(state, player2, level, rolls(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action.Type != ACTION_SWITCH || action.Position == 1 {
+				t.Fatalf("expected a switch to a pokemon able to battle, got %+v", action)
+			}
+			if _, err := e.Validate(state, action); err != nil {
+				t.Fatalf("the bot chose an invalid switch: %v", err)
+			}
+		})
+	}
+}
+
+func TestBotWaitsForReplacement(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT, BLAZE}, []int32{SLUGGISH})
+	faint(&state.Player1)
+
+	if _, err := e.BotAction(state, player2, BOT_LEVEL_GREEDY, nil); err == nil {
+		t.Fatal("the bot should wait while its opponent replaces a fainted pokemon")
+	}
+}
+
+func TestBotUnknownLevel(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	if _, err := e.BotAction(state, player2, "cheater", nil); err == nil {
+		t.Fatal("an unknown level should be rejected")
+	}
+}
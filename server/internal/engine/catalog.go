@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+)
+
+// Name of the fallback move used when the active pokemon has no PP left
+const STRUGGLE_MOVE_NAME = "Struggle"
+
+// Species are the base stats shared by every pokemon of a kind
+type Species struct {
+	ID          int32
+	Name        string
+	BaseHP      int32
+	BaseAttack  int32
+	BaseDefense int32
+	BaseSpeed   int32
+	Type1       string
+	Type2       string // Empty for single type species
+}
+
+// Move is a move as defined in the game data
+type Move struct {
+	ID       int32
+	Name     string
+	Type     string
+	Power    int32
+	Accuracy int32
+	PP       int32
+	Priority int32
+	Effects  []Effect // Run in order when the move hits
+}
+
+// Catalog is the game data battles are played with
+type Catalog struct {
+	Species      map[int32]Species
+	Moves        map[int32]Move
+	SpeciesMoves map[int32][]int32 // Moves each species knows, by move id
+}
+
+// Struggle returns the move used when a pokemon has no PP left
+func (c *Catalog) Struggle() (Move, error) {
+	for _, move := range c.Moves {
+		if move.Name == STRUGGLE_MOVE_NAME {
+			return move, nil
+		}
+	}
+	return Move{}, fmt.Errorf("the catalog has no %s move", STRUGGLE_MOVE_NAME)
+}
+
+// NewPokemon returns a team member at full health, with the full PP of every move its species knows
+func (c *Catalog) NewPokemon(position, speciesID int32) (Pokemon, error) {
+	species, ok := c.Species[speciesID]
+	if !ok {
+		return Pokemon{}, fmt.Errorf("unknown species %d", speciesID)
+	}
+
+	poke := Pokemon{
+		Position:  position,
+		SpeciesID: speciesID,
+		HP:        species.BaseHP,
+		Moves:     make([]MoveSlot, 0, len(c.SpeciesMoves[speciesID])),
+	}
+	for _, moveID := range c.SpeciesMoves[speciesID] {
+		move := c.Moves[moveID]
+		poke.Moves = append(poke.Moves, MoveSlot{
+			MoveID: move.ID,
+			Name:   move.Name,
+			PP:     move.PP,
+			MaxPP:  move.PP,
+		})
+	}
+	return poke, nil
+}
@@ -0,0 +1,36 @@
+package engine
+
+// BATTLE_LEVEL is the level every pokemon fights at, used by the damage formula
+const BATTLE_LEVEL = 50
+
+// Struggle hurts the user by 1/STRUGGLE_RECOIL_FRACTION of its max HP
+const STRUGGLE_RECOIL_FRACTION = 4
+
+// damage returns what a move deals and the type multiplier it got, with the stat stages
+// and status of both pokemon applied. roll is the multiplier of the attack roll, see AttackRoll.
+func (e *Engine) damage(attacker, defender *Pokemon, move Move, roll float64) (int32, float64) {
+	attackerSpecies := e.Catalog.Species[attacker.SpeciesID]
+	defenderSpecies := e.Catalog.Species[defender.SpeciesID]
+	effectiveness := e.typeChart().Effectiveness(move.Type, defenderSpecies.Type1, defenderSpecies.Type2)
+	stab := STAB(move.Type, attackerSpecies.Type1, attackerSpecies.Type2)
+	modifier := stab * effectiveness * roll * attackMultiplier(attacker)
+	return calculateDamage(move.Power, e.stat(attacker, STAT_ATTACK), e.stat(defender, STAT_DEFENSE), modifier), effectiveness
+}
+
+// calculateDamage applies the classic damage formula using the move power and
+// the attacker/defender base stats, scaled by modifier (STAB x type effectiveness).
+// Every hit that is not fully resisted deals at least 1 damage, moves without power deal none.
+func calculateDamage(power, attack, defense int32, modifier float64) int32 {
+	if modifier == 0 || power == 0 {
+		return 0
+	}
+	if defense <= 0 {
+		defense = 1
+	}
+	base := ((2*BATTLE_LEVEL/5+2)*power*attack/defense)/50 + 2
+	damage := int32(float64(base) * modifier)
+	if damage < 1 {
+		damage = 1
+	}
+	return damage
+}
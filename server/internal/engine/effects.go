@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Kinds of secondary effects a move can have, see Effect
+const (
+	EFFECT_STATUS    = "status"    // Inflicts Status
+	EFFECT_RECOIL    = "recoil"    // The user loses Magnitude % of the damage dealt
+	EFFECT_DRAIN     = "drain"     // The user heals Magnitude % of the damage dealt
+	EFFECT_FLINCH    = "flinch"    // The target loses its move this turn if it has not moved yet
+	EFFECT_MULTI_HIT = "multi_hit" // The move hits 2 to Magnitude times
+	EFFECT_RECHARGE  = "recharge"  // The user can't move next turn
+	EFFECT_STAT      = "stat"      // Raises Stat by Magnitude stages, lowers it when negative
+)
+
+// Who an effect applies to
+const (
+	EFFECT_TARGET_TARGET = "target"
+	EFFECT_TARGET_USER   = "user"
+)
+
+// Kinds of events caused by move effects, besides EVENT_RECOIL, EVENT_STATUS and EVENT_CANT_MOVE
+const (
+	EVENT_DRAIN = "drain" // The user of a draining move healed
+)
+
+var effectKinds = []string{EFFECT_STATUS, EFFECT_RECOIL, EFFECT_DRAIN, EFFECT_FLINCH, EFFECT_MULTI_HIT, EFFECT_RECHARGE, EFFECT_STAT}
+
+// Effect is a secondary effect of a move, run when the move hits
+type Effect struct {
+	Kind      string // One of EFFECT_*
+	Target    string // EFFECT_TARGET_TARGET or EFFECT_TARGET_USER
+	Chance    int32  // Chance in percent of the effect happening
+	Magnitude int32  // Meaning depends on Kind
+	Status    string // Status inflicted by EFFECT_STATUS, see STATUS_*
+	Stat      string // Stat changed by EFFECT_STAT, see STAT_*
+}
+
+// Validate checks the effect is one the engine knows how to run
+func (eff Effect) Validate() error {
+	if !slices.Contains(effectKinds, eff.Kind) {
+		return fmt.Errorf("unknown effect kind %q", eff.Kind)
+	}
+	if eff.Target != EFFECT_TARGET_TARGET && eff.Target != EFFECT_TARGET_USER {
+		return fmt.Errorf("unknown effect target %q", eff.Target)
+	}
+	if _, ok := statusMessages[eff.Status]; eff.Kind == EFFECT_STATUS && !ok {
+		return fmt.Errorf("unknown status %q", eff.Status)
+	}
+	if eff.Kind == EFFECT_STAT && (!slices.Contains(Stats, eff.Stat) || eff.Magnitude == 0) {
+		return fmt.Errorf("stat effects change attack, defense or speed by at least one stage, got %q by %d", eff.Stat, eff.Magnitude)
+	}
+	if eff.Kind == EFFECT_MULTI_HIT && eff.Magnitude < 2 {
+		return fmt.Errorf("multi hit moves hit at least twice, got %d", eff.Magnitude)
+	}
+	return nil
+}
+
+// Effect returns the first effect of the given kind the move has
+func (m Move) Effect(kind string) (Effect, bool) {
+	for _, effect := range m.Effects {
+		if effect.Kind == kind {
+			return effect, true
+		}
+	}
+	return Effect{}, false
+}
+
+// hitCount rolls how many times a move hits
+func hitCount(r Roller, move Move) int {
+	effect, ok := move.Effect(EFFECT_MULTI_HIT)
+	if !ok {
+		return 1
+	}
+	return 2 + r.IntN(int(effect.Magnitude)-1)
+}
+
+// expectedHits is how many times a move hits on average
+func expectedHits(move Move) float64 {
+	effect, ok := move.Effect(EFFECT_MULTI_HIT)
+	if !ok {
+		return 1
+	}
+	return float64(2+effect.Magnitude) / 2
+}
+
+// rollChance reports whether an effect with the given chance happens, effects that
+// always happen don't use up a roll
+func rollChance(r Roller, chance int32) bool {
+	return chance >= 100 || r.IntN(100) < int(chance)
+}
+
+// lostTurn checks whether a pokemon has to sit out this turn because it is recharging or flinched
+func (e *Engine) lostTurn(playerID pgtype.UUID, poke *Pokemon) (Event, bool) {
+	name := e.Catalog.Species[poke.SpeciesID].Name
+	message := ""
+	switch {
+	case poke.Recharging:
+		poke.Recharging = false
+		message = fmt.Sprintf("%s must recharge!", name)
+	case poke.Flinched:
+		message = fmt.Sprintf("%s flinched and couldn't move!", name)
+	default:
+		return Event{}, false
+	}
+	return Event{
+		Type:        EVENT_CANT_MOVE,
+		PlayerID:    playerID,
+		Position:    poke.Position,
+		RemainingHP: poke.HP,
+		Message:     message,
+	}, true
+}
+
+// applyEffects runs the effects of a move that hit, in order, after it dealt damage.
+// Multi hit is left out, it is rolled before the move hits.
+func (e *Engine) applyEffects(r Roller, entry orderedAction, damage int32) []Event {
+	attacker := entry.side.Active()
+	defender := entry.opponent.Active()
+	// Moves without power always land, the others only when they dealt damage
+	landed := damage > 0 || entry.move.Power == 0
+	events := []Event{}
+	for _, effect := range entry.move.Effects {
+		target, targetID := defender, entry.opponent.PlayerID
+		if effect.Target == EFFECT_TARGET_USER {
+			target, targetID = attacker, entry.side.PlayerID
+		}
+		if target.Fainted {
+			continue
+		}
+
+		switch effect.Kind {
+		case EFFECT_STATUS:
+			if landed {
+				events = append(events, e.tryInflict(r, targetID, target, effect.Status, effect.Chance)...)
+			}
+
+		case EFFECT_FLINCH:
+			if landed && rollChance(r, effect.Chance) {
+				target.Flinched = true
+			}
+
+		case EFFECT_RECOIL:
+			if damage > 0 && rollChance(r, effect.Chance) {
+				events = append(events, e.recoil(targetID, target, max(damage*effect.Magnitude/100, 1))...)
+			}
+
+		case EFFECT_DRAIN:
+			if damage > 0 && rollChance(r, effect.Chance) {
+				if event, ok := e.drain(targetID, target, max(damage*effect.Magnitude/100, 1)); ok {
+					events = append(events, event)
+				}
+			}
+
+		case EFFECT_RECHARGE:
+			if rollChance(r, effect.Chance) {
+				target.Recharging = true
+			}
+
+		case EFFECT_STAT:
+			if landed && rollChance(r, effect.Chance) {
+				events = append(events, e.changeStage(targetID, target, effect.Stat, effect.Magnitude))
+			}
+		}
+	}
+	return events
+}
+
+// recoil hurts the user of a move
+func (e *Engine) recoil(playerID pgtype.UUID, poke *Pokemon, damage int32) []Event {
+	species := e.Catalog.Species[poke.SpeciesID]
+	poke.applyDamage(damage)
+	events := []Event{{
+		Type:        EVENT_RECOIL,
+		PlayerID:    playerID,
+		Position:    poke.Position,
+		Damage:      damage,
+		RemainingHP: poke.HP,
+		Message:     fmt.Sprintf("%s is hit with %d recoil!", species.Name, damage),
+	}}
+	if poke.Fainted {
+		events = append(events, faintEvent(playerID, poke, species))
+	}
+	return events
+}
+
+// drain heals the user of a move, never above its max HP
+func (e *Engine) drain(playerID pgtype.UUID, poke *Pokemon, amount int32) (Event, bool) {
+	species := e.Catalog.Species[poke.SpeciesID]
+	amount = min(amount, species.BaseHP-poke.HP)
+	if amount <= 0 {
+		return Event{}, false
+	}
+	poke.HP += amount
+	return Event{
+		Type:        EVENT_DRAIN,
+		PlayerID:    playerID,
+		Position:    poke.Position,
+		RemainingHP: poke.HP,
+		Message:     fmt.Sprintf("%s drained %d HP!", species.Name, amount),
+	}, true
+}
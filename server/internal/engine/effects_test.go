@@ -0,0 +1,249 @@
+package engine
+
+import (
+	"testing"
+)
+
+var (
+	doubleEdge = Move{ID: 20, Name: "Double-Edge", Type: "normal", Power: 120, Accuracy: 100, PP: 15, Effects: []Effect{
+		{Kind: EFFECT_RECOIL, Target: EFFECT_TARGET_USER, Chance: 100, Magnitude: 33},
+	}}
+	gigaDrain = Move{ID: 21, Name: "Giga Drain", Type: "normal", Power: 60, Accuracy: 100, PP: 10, Effects: []Effect{
+		{Kind: EFFECT_DRAIN, Target: EFFECT_TARGET_USER, Chance: 100, Magnitude: 50},
+	}}
+	headbutt = Move{ID: 22, Name: "Headbutt", Type: "normal", Power: 40, Accuracy: 100, PP: 15, Effects: []Effect{
+		{Kind: EFFECT_FLINCH, Target: EFFECT_TARGET_TARGET, Chance: 100},
+	}}
+	furyAttack = Move{ID: 23, Name: "Fury Attack", Type: "normal", Power: 15, Accuracy: 100, PP: 20, Effects: []Effect{
+		{Kind: EFFECT_MULTI_HIT, Target: EFFECT_TARGET_TARGET, Chance: 100, Magnitude: 5},
+	}}
+	hyperBeam = Move{ID: 24, Name: "Hyper Beam", Type: "normal", Power: 100, Accuracy: 100, PP: 5, Effects: []Effect{
+		{Kind: EFFECT_RECHARGE, Target: EFFECT_TARGET_USER, Chance: 100},
+	}}
+	bodySlam = Move{ID: 25, Name: "Body Slam", Type: "normal", Power: 40, Accuracy: 100, PP: 15, Effects: []Effect{
+		{Kind: EFFECT_STATUS, Target: EFFECT_TARGET_TARGET, Chance: 100, Status: STATUS_PARALYSIS},
+	}}
+)
+
+// putToSleep keeps the active pokemon of a side from moving this turn, so it can't change
+// the HP of its opponent
+func putToSleep(side *Side) {
+	side.Active().Status = STATUS_SLEEP
+	side.Active().StatusTurns = 1
+}
+
+// Normal moves deal no damage to ghosts
+var phantom = Species{ID: 7, Name: "Phantom", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 30, Type1: "ghost"}
+
+func TestRecoil(t *testing.T) {
+	e := testEngine(rolls(99), doubleEdge)
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	putToSleep(&state.Player2)
+
+	next, events := playTurn(t, e, state, attack(player1, doubleEdge), attack(player2, tackle))
+	hit, _ := findEvent(events, EVENT_ATTACK, player1)
+	recoil, ok := findEvent(events, EVENT_RECOIL, player1)
+	if !ok || recoil.Damage != hit.Damage*33/100 {
+		t.Fatalf("expected a third of %d damage as recoil, got %v", hit.Damage, recoil)
+	}
+	if next.Player1.Active().HP != 100-recoil.Damage {
+		t.Fatal("recoil should hurt the user")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		hp      int32
+		healed  bool
+		finalHP func(damage int32) int32
+	}{
+		{"heals half the damage", 50, true, func(damage int32) int32 { return 50 + damage/2 }},
+		{"stops at max HP", 99, true, func(int32) int32 { return 100 }},
+		{"does nothing at max HP", 100, false, func(int32) int32 { return 100 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(rolls(99), gigaDrain)
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+			state.Player1.Team[0].HP = tt.hp
+
+			putToSleep(&state.Player2)
+
+			next, events := playTurn(t, e, state, attack(player1, gigaDrain), attack(player2, tackle))
+			hit, _ := findEvent(events, EVENT_ATTACK, player1)
+			if _, drained := findEvent(events, EVENT_DRAIN, player1); drained != tt.healed {
+				t.Fatalf("got %v", eventTypes(events))
+			}
+			if hp := next.Player1.Active().HP; hp != tt.finalHP(hit.Damage) {
+				t.Fatalf("expected %d HP, got %d", tt.finalHP(hit.Damage), hp)
+			}
+		})
+	}
+}
+
+func TestFlinchOnlyWhenMovingFirst(t *testing.T) {
+	e := testEngine(rolls(99), headbutt)
+
+	// The faster pokemon makes the target flinch
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	next, events := playTurn(t, e, state, attack(player1, headbutt), attack(player2, tackle))
+	if _, flinched := findEvent(events, EVENT_CANT_MOVE, player2); !flinched {
+		t.Fatalf("the target should flinch, got %v", eventTypes(events))
+	}
+	if next.Player2.Active().Flinched {
+		t.Fatal("flinching should only last for the turn")
+	}
+
+	// The slower one hits after the target already moved
+	state = newBattle(t, e, []int32{SLUGGISH}, []int32{SWIFT})
+	_, events = playTurn(t, e, state, attack(player1, headbutt), attack(player2, tackle))
+	if _, flinched := findEvent(events, EVENT_CANT_MOVE, player2); flinched {
+		t.Fatal("a target that already moved can't flinch")
+	}
+	if len(actors(events, EVENT_ATTACK)) != 2 {
+		t.Fatalf("both should attack, got %v", eventTypes(events))
+	}
+}
+
+func TestMultiHit(t *testing.T) {
+	tests := []struct {
+		roll int
+		hits int
+	}{
+		{0, 2},
+		{99, 5},
+	}
+	for _, tt := range tests {
+		e := testEngine(rolls(tt.roll), furyAttack)
+		state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+		next, events := playTurn(t, e, state, attack(player1, furyAttack), attack(player2, tackle))
+		hits := 0
+		var total int32
+		for _, event := range events {
+			if event.Type == EVENT_ATTACK && event.PlayerID == player1 {
+				hits++
+				total += event.Damage
+			}
+		}
+		if hits != tt.hits {
+			t.Fatalf("roll %d: expected %d hits, got %d", tt.roll, tt.hits, hits)
+		}
+		if next.Player2.Active().HP != 100-total {
+			t.Fatalf("roll %d: every hit should deal its damage", tt.roll)
+		}
+	}
+}
+
+func TestMultiHitStopsOnFaint(t *testing.T) {
+	e := testEngine(rolls(99), furyAttack)
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH, SLUGGISH})
+	state.Player2.Team[0].HP = 1
+
+	_, events := playTurn(t, e, state, attack(player1, furyAttack), attack(player2, tackle))
+	if len(actors(events, EVENT_ATTACK)) != 1 {
+		t.Fatalf("the move should stop once the target faints, got %v", eventTypes(events))
+	}
+}
+
+func TestRechargeSkipsNextTurn(t *testing.T) {
+	e := testEngine(rolls(99), hyperBeam)
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	state, _ = playTurn(t, e, state, attack(player1, hyperBeam), attack(player2, tackle))
+	if !state.Player1.Active().Recharging {
+		t.Fatal("the user should have to recharge")
+	}
+	pp := state.Player1.Active().Move(tackle.ID).PP
+
+	state, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if _, lost := findEvent(events, EVENT_CANT_MOVE, player1); !lost || len(actors(events, EVENT_ATTACK)) != 1 {
+		t.Fatalf("the user should sit the turn out, got %v", eventTypes(events))
+	}
+	if state.Player1.Active().Move(tackle.ID).PP != pp {
+		t.Fatal("recharging should not spend PP")
+	}
+
+	_, events = playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if len(actors(events, EVENT_ATTACK)) != 2 {
+		t.Fatalf("the user should move again after recharging, got %v", eventTypes(events))
+	}
+}
+
+func TestRechargeBlocksSwitching(t *testing.T) {
+	e := testEngine(rolls(99), hyperBeam)
+	state := newBattle(t, e, []int32{SWIFT, SWIFT}, []int32{SLUGGISH})
+
+	state, _ = playTurn(t, e, state, attack(player1, hyperBeam), attack(player2, tackle))
+	if _, err := e.Validate(state, switchTo(player1, 2)); err == nil {
+		t.Fatal("switching out should not skip the recharge")
+	}
+	for _, option := range Options(state, player1) {
+		if option.Type == ACTION_SWITCH {
+			t.Fatal("bots should not be offered to switch out while recharging")
+		}
+	}
+}
+
+func TestRechargeEndsOnFaint(t *testing.T) {
+	e := testEngine(rolls(99), hyperBeam)
+	state := newBattle(t, e, []int32{SWIFT, SWIFT}, []int32{SLUGGISH})
+
+	state, _ = playTurn(t, e, state, attack(player1, hyperBeam), attack(player2, tackle))
+	state.Player1.Team[0].HP = 1
+	state, _ = playTurn(t, e, state, attack(player1, tackle), attack(player2, knockOut))
+	if !state.MustSwitch(player1) {
+		t.Fatal("the recharging pokemon should have fainted")
+	}
+
+	state, _, err := e.Apply(state, switchTo(player1, 2))
+	if err != nil {
+		t.Fatalf("a fainted pokemon can be replaced while recharging: %v", err)
+	}
+	if state.Player1.Team[0].Recharging {
+		t.Fatal("the recharge should end with the pokemon leaving the field")
+	}
+}
+
+func TestEffectsNeedTheMoveToLand(t *testing.T) {
+	e := testEngine(rolls(99), bodySlam, willOWisp)
+	e.Catalog.Species[phantom.ID] = phantom
+	e.Catalog.SpeciesMoves[phantom.ID] = e.Catalog.SpeciesMoves[SLUGGISH]
+
+	// A move that deals no damage has no effects
+	state := newBattle(t, e, []int32{SWIFT}, []int32{phantom.ID})
+	next, events := playTurn(t, e, state, attack(player1, bodySlam), attack(player2, tackle))
+	if _, got := findEvent(events, EVENT_STATUS, player2); got || next.Player2.Active().Status != "" {
+		t.Fatal("a move that did no damage should not paralyze")
+	}
+
+	// Moves without power always land
+	next, events = playTurn(t, e, state, attack(player1, willOWisp), attack(player2, tackle))
+	if _, got := findEvent(events, EVENT_STATUS, player2); !got || next.Player2.Active().Status != STATUS_BURN {
+		t.Fatal("a move without power should still burn")
+	}
+
+	// A miss has no effects either
+	e = testEngine(rolls(99), bodySlam)
+	bodySlam := bodySlam
+	bodySlam.Accuracy = 50
+	e.Catalog.Moves[bodySlam.ID] = bodySlam
+	state = newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	next, events = playTurn(t, e, state, attack(player1, bodySlam), attack(player2, tackle))
+	if _, missed := findEvent(events, EVENT_MISS, player1); !missed || next.Player2.Active().Status != "" {
+		t.Fatalf("a missed move should not paralyze, got %v", eventTypes(events))
+	}
+}
+
+func TestGuaranteedEffectsDontRoll(t *testing.T) {
+	e := testEngine(rolls(99))
+	r := rolls(0, 99)
+	poke := Pokemon{SpeciesID: SLUGGISH, HP: 100}
+
+	// The 0 is left for the sleep turns, the chance of 100 takes no roll
+	e.tryInflict(r, player2, &poke, STATUS_SLEEP, 100)
+	if poke.StatusTurns != SLEEP_MIN_TURNS {
+		t.Fatalf("a 100%% status should not use up a roll, slept %d turns", poke.StatusTurns)
+	}
+}
@@ -0,0 +1,398 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Engine applies the battle rules to a BattleState. It never touches the database,
+// so live battles, bots, replays and simulations all play by the same implementation.
+type Engine struct {
+	Catalog   *Catalog
+	TypeChart TypeChart     // Falls back to DefaultTypeChart when nil
+	NewRoller RollerFactory // Falls back to SeededRoller when nil
+}
+
+// typeChart returns the configured type chart or the embedded default
+func (e *Engine) typeChart() TypeChart {
+	if e.TypeChart == nil {
+		return DefaultTypeChart
+	}
+	return e.TypeChart
+}
+
+// roller returns the random source for a battle turn
+func (e *Engine) roller(seed int64, turn int32) Roller {
+	if e.NewRoller == nil {
+		return SeededRoller(seed, turn)
+	}
+	return e.NewRoller(seed, turn)
+}
+
+// Validate checks the action can be chosen by its player this turn and returns the action
+// to perform: a move chosen while every move is out of PP becomes Struggle.
+func (e *Engine) Validate(state BattleState, action Action) (Action, error) {
+	if _, ended := state.Winner(); ended {
+		return action, fmt.Errorf("battle is over")
+	}
+	side, _, err := state.Sides(action.PlayerID)
+	if err != nil {
+		return action, err
+	}
+	for _, pending := range state.Pending {
+		if pending.PlayerID == action.PlayerID {
+			return action, fmt.Errorf("action already submitted for turn %d", state.Turn)
+		}
+	}
+
+	// Fainted pokemon are replaced before anyone chooses the next action
+	if state.Switching() {
+		if !state.MustSwitch(action.PlayerID) {
+			return action, fmt.Errorf("waiting for the opponent to replace its fainted pokemon")
+		}
+		if action.Type != ACTION_SWITCH {
+			return action, fmt.Errorf("your active pokemon fainted, choose the pokemon to send out")
+		}
+	}
+
+	active := side.Active()
+	if active == nil {
+		return action, fmt.Errorf("no active pokemon at position %d", side.ActivePos)
+	}
+
+	switch action.Type {
+	case ACTION_ATTACK:
+		if len(active.Moves) > 0 && !active.hasPPLeft() {
+			struggle, err := e.Catalog.Struggle()
+			if err != nil {
+				return action, err
+			}
+			action.MoveID = struggle.ID
+			return action, nil
+		}
+		move := active.Move(action.MoveID)
+		if move == nil {
+			return action, fmt.Errorf("move %d is not known by the active pokemon", action.MoveID)
+		}
+		if move.PP <= 0 {
+			return action, fmt.Errorf("no PP left for %s", move.Name)
+		}
+		return action, nil
+
+	case ACTION_SWITCH:
+		target := side.Pokemon(action.Position)
+		if target == nil {
+			return action, fmt.Errorf("no pokemon at position %d", action.Position)
+		}
+		if target.Fainted || target.HP <= 0 {
+			return action, fmt.Errorf("cannot switch to a fainted pokemon")
+		}
+		if side.ActivePos == action.Position {
+			return action, fmt.Errorf("pokemon is already active")
+		}
+		// A pokemon that must recharge can't dodge its lost turn by switching out
+		if active.Recharging && !state.MustSwitch(action.PlayerID) {
+			return action, fmt.Errorf("%s must recharge", e.Catalog.Species[active.SpeciesID].Name)
+		}
+		return action, nil
+
+	default:
+		return action, fmt.Errorf("unknown action type %q", action.Type)
+	}
+}
+
+// Apply registers the action of a player for the current turn and returns the new state.
+// Once both players chose their action the turn is resolved: the new state is on the next
+// turn and the events tell what happened, in order. Until then there are no events.
+// A switch replacing a fainted pokemon, see BattleState.MustSwitch, happens right away
+// and stays on the same turn.
+func (e *Engine) Apply(state BattleState, action Action) (BattleState, []Event, error) {
+	action, err := e.Validate(state, action)
+	if err != nil {
+		return state, nil, err
+	}
+	return e.ApplyValidated(state, action)
+}
+
+// ApplyValidated is Apply for an action Validate already returned, for callers that need
+// the action to perform before applying it. Validate must have checked it against state.
+func (e *Engine) ApplyValidated(state BattleState, action Action) (BattleState, []Event, error) {
+	next := state.Clone()
+	if next.MustSwitch(action.PlayerID) {
+		side, _, _ := next.Sides(action.PlayerID)
+		return next, []Event{e.switchIn(side, action.Position)}, nil
+	}
+
+	next.Pending = append(next.Pending, action)
+	if len(next.Pending) < 2 {
+		return next, []Event{}, nil
+	}
+
+	events, err := e.resolveTurn(&next, next.Pending)
+	if err != nil {
+		return state, nil, err
+	}
+	next.Pending = nil
+	next.Turn++
+	return next, events, nil
+}
+
+// orderedAction is a chosen action with everything needed to sort it
+type orderedAction struct {
+	action   Action
+	side     *Side
+	opponent *Side
+	actorPos int32 // Active pokemon when the action was chosen
+	move     Move
+	priority int32
+	speed    int32
+}
+
+// resolveTurn runs both actions of a turn: switches first, then moves by priority and speed
+func (e *Engine) resolveTurn(state *BattleState, actions []Action) ([]Event, error) {
+	r := e.roller(state.Seed, state.Turn)
+
+	ordered := make([]orderedAction, 0, len(actions))
+	for _, action := range actions {
+		side, opponent, err := state.Sides(action.PlayerID)
+		if err != nil {
+			return nil, err
+		}
+		entry := orderedAction{
+			action:   action,
+			side:     side,
+			opponent: opponent,
+			actorPos: side.ActivePos,
+			priority: SWITCH_PRIORITY,
+			speed:    e.speed(side.Active()),
+		}
+		if action.Type == ACTION_ATTACK {
+			move, ok := e.Catalog.Moves[action.MoveID]
+			if !ok {
+				return nil, fmt.Errorf("unknown move %d", action.MoveID)
+			}
+			entry.move = move
+			entry.priority = move.Priority
+		}
+		ordered = append(ordered, entry)
+	}
+
+	// Order both actions, speed ties are decided by a coin flip
+	if len(ordered) == 2 {
+		first, second := ordered[0], ordered[1]
+		if second.priority > first.priority ||
+			(second.priority == first.priority && second.speed > first.speed) ||
+			(second.priority == first.priority && second.speed == first.speed && r.IntN(2) == 1) {
+			ordered[0], ordered[1] = second, first
+		}
+	}
+
+	events := []Event{}
+	for _, entry := range ordered {
+		switch entry.action.Type {
+		case ACTION_SWITCH:
+			events = append(events, e.switchIn(entry.side, entry.action.Position))
+		case ACTION_ATTACK:
+			attackEvents, err := e.useMove(r, entry)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, attackEvents...)
+		}
+	}
+
+	// Flinching only lasts for the turn it happened in
+	for _, side := range []*Side{&state.Player1, &state.Player2} {
+		for i := range side.Team {
+			side.Team[i].Flinched = false
+		}
+	}
+
+	// Burn and poison hurt at the end of the turn
+	events = append(events, e.statusDamage(&state.Player1)...)
+	events = append(events, e.statusDamage(&state.Player2)...)
+
+	// Fainted pokemon stay on the field until their players choose a replacement
+	return events, nil
+}
+
+// switchIn makes the pokemon at position the active one of the side
+func (e *Engine) switchIn(side *Side, position int32) Event {
+	// Leaving the field resets the stat stages of a pokemon, and the recharge turn a fainted one had left
+	if active := side.Active(); active != nil {
+		active.Recharging = false
+		active.Stages = nil
+	}
+	side.ActivePos = position
+	poke := side.Active()
+	return Event{
+		Type:        EVENT_SWITCH,
+		PlayerID:    side.PlayerID,
+		Position:    position,
+		RemainingHP: poke.HP,
+		Message:     fmt.Sprintf("Go! %s!", e.Catalog.Species[poke.SpeciesID].Name),
+	}
+}
+
+// useMove resolves a single attack against the opponent's active pokemon
+func (e *Engine) useMove(r Roller, entry orderedAction) ([]Event, error) {
+	attacker := entry.side.Active()
+
+	// A pokemon that fainted (or left the field) before acting loses its turn
+	if entry.side.ActivePos != entry.actorPos || attacker == nil || attacker.Fainted {
+		return nil, nil
+	}
+
+	// Recharging, flinching, sleep, freeze and paralysis may keep it from moving, without spending PP
+	if event, lost := e.lostTurn(entry.side.PlayerID, attacker); lost {
+		return []Event{event}, nil
+	}
+	canMove, events := e.canMove(r, entry.side.PlayerID, attacker)
+	if !canMove {
+		return events, nil
+	}
+
+	move := entry.move
+	isStruggle := move.Name == STRUGGLE_MOVE_NAME
+	if !isStruggle {
+		slot := attacker.Move(move.ID)
+		if slot == nil || slot.PP <= 0 {
+			return nil, fmt.Errorf("no PP left for move %d", move.ID)
+		}
+		slot.PP--
+	}
+
+	attackerSpecies := e.Catalog.Species[attacker.SpeciesID]
+	defender := entry.opponent.Active()
+	if defender == nil || defender.Fainted {
+		return append(events, Event{
+			Type:     EVENT_MISS,
+			PlayerID: entry.side.PlayerID,
+			Position: attacker.Position,
+			MoveID:   move.ID,
+			MoveName: move.Name,
+			Message:  fmt.Sprintf("%s used %s! But there was no target...", attackerSpecies.Name, move.Name),
+		}), nil
+	}
+
+	// Roll accuracy, critical hit and damage variance from the battle seed
+	roll := rollAttack(r, move.Accuracy)
+	if !roll.Hit {
+		return append(events, Event{
+			Type:        EVENT_MISS,
+			PlayerID:    entry.side.PlayerID,
+			Position:    attacker.Position,
+			MoveID:      move.ID,
+			MoveName:    move.Name,
+			RemainingHP: defender.HP,
+			Message:     fmt.Sprintf("%s used %s! But it missed!", attackerSpecies.Name, move.Name),
+		}), nil
+	}
+
+	// Moves without power skip straight to their effects
+	var total int32
+	if move.Power == 0 {
+		events = append(events, Event{
+			Type:        EVENT_MOVE,
+			PlayerID:    entry.side.PlayerID,
+			Position:    attacker.Position,
+			MoveID:      move.ID,
+			MoveName:    move.Name,
+			RemainingHP: defender.HP,
+			Message:     fmt.Sprintf("%s used %s!", attackerSpecies.Name, move.Name),
+		})
+	} else {
+		var hitEvents []Event
+		hitEvents, total = e.strike(r, entry, roll)
+		events = append(events, hitEvents...)
+	}
+
+	events = append(events, e.applyEffects(r, entry, total)...)
+
+	// Struggle hurts the user as well
+	if isStruggle && !attacker.Fainted {
+		events = append(events, e.recoil(entry.side.PlayerID, attacker, max(attackerSpecies.BaseHP/STRUGGLE_RECOIL_FRACTION, 1))...)
+	}
+
+	return events, nil
+}
+
+// strike deals the damage of a move that hit, once per hit, and returns the damage dealt in total
+func (e *Engine) strike(r Roller, entry orderedAction, roll AttackRoll) ([]Event, int32) {
+	attacker, defender, move := entry.side.Active(), entry.opponent.Active(), entry.move
+	attackerSpecies := e.Catalog.Species[attacker.SpeciesID]
+	defenderSpecies := e.Catalog.Species[defender.SpeciesID]
+
+	events := []Event{}
+	var total int32
+	hits := hitCount(r, move)
+	for hit := range hits {
+		if hit > 0 {
+			// Only the first hit can miss, the others roll their own critical hit and damage
+			roll = rollAttack(r, 100)
+		}
+		damage, effectiveness := e.damage(attacker, defender, move, roll.multiplier())
+		defender.applyDamage(damage)
+		total += damage
+
+		critMessage := ""
+		if roll.CriticalHit {
+			critMessage = " A critical hit!"
+		}
+		message := fmt.Sprintf("%s used %s!%s%s Attack dealt %d damage! Defender's HP: %d",
+			attackerSpecies.Name, move.Name, critMessage, effectivenessMessage(effectiveness), damage, defender.HP)
+		if hit > 0 {
+			message = fmt.Sprintf("Hit %d!%s Attack dealt %d damage! Defender's HP: %d", hit+1, critMessage, damage, defender.HP)
+		}
+		events = append(events, Event{
+			Type:          EVENT_ATTACK,
+			PlayerID:      entry.side.PlayerID,
+			Position:      attacker.Position,
+			MoveID:        move.ID,
+			MoveName:      move.Name,
+			Damage:        damage,
+			RemainingHP:   defender.HP,
+			Effectiveness: EffectivenessLabel(effectiveness),
+			CriticalHit:   roll.CriticalHit,
+			Message:       message,
+		})
+		if defender.Fainted {
+			events = append(events, faintEvent(entry.opponent.PlayerID, defender, defenderSpecies))
+			break
+		}
+	}
+	return events, total
+}
+
+func faintEvent(playerID pgtype.UUID, poke *Pokemon, species Species) Event {
+	return Event{
+		Type:     EVENT_FAINT,
+		PlayerID: playerID,
+		Position: poke.Position,
+		Message:  fmt.Sprintf("%s fainted!", species.Name),
+	}
+}
+
+// DefaultAction returns the action taken for a player that ran out of time:
+// the first move of their active pokemon with PP left. When none has, Validate
+// replaces it with Struggle. A fainted pokemon is replaced by the first one able to battle.
+func DefaultAction(state BattleState, playerID pgtype.UUID) (Action, error) {
+	side, _, err := state.Sides(playerID)
+	if err != nil {
+		return Action{}, err
+	}
+	if side.mustSwitch() {
+		return Action{PlayerID: playerID, Type: ACTION_SWITCH, Position: side.nextAvailable().Position}, nil
+	}
+	active := side.Active()
+	if active == nil || len(active.Moves) == 0 {
+		return Action{}, fmt.Errorf("active pokemon has no moves")
+	}
+	for _, move := range active.Moves {
+		if move.PP > 0 {
+			return Action{PlayerID: playerID, Type: ACTION_ATTACK, MoveID: move.MoveID}, nil
+		}
+	}
+	return Action{PlayerID: playerID, Type: ACTION_ATTACK, MoveID: active.Moves[0].MoveID}, nil
+}
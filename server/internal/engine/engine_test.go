@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	player1 = pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	player2 = pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+)
+
+// Species of the test catalog, every one of them knows every move
+var testSpecies = []Species{
+	{ID: 1, Name: "Swift", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 90, Type1: "normal"},
+	{ID: 2, Name: "Sluggish", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 30, Type1: "normal"},
+	{ID: 3, Name: "Blaze", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 60, Type1: "fire"},
+	{ID: 4, Name: "Volt", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 60, Type1: "electric"},
+	{ID: 5, Name: "Venom", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 60, Type1: "poison"},
+	{ID: 6, Name: "Glacier", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 60, Type1: "ice"},
+}
+
+const (
+	SWIFT    = 1
+	SLUGGISH = 2
+	BLAZE    = 3
+	VOLT     = 4
+	VENOM    = 5
+	GLACIER  = 6
+)
+
+var (
+	tackle      = Move{ID: 1, Name: "Tackle", Type: "normal", Power: 40, Accuracy: 100, PP: 35}
+	quickAttack = Move{ID: 2, Name: "Quick Attack", Type: "normal", Power: 40, Accuracy: 100, PP: 30, Priority: 1}
+	knockOut    = Move{ID: 3, Name: "Knock Out", Type: "normal", Power: 250, Accuracy: 100, PP: 5}
+	wildSwing   = Move{ID: 4, Name: "Wild Swing", Type: "normal", Power: 40, Accuracy: 50, PP: 10}
+	struggle    = Move{ID: 5, Name: STRUGGLE_MOVE_NAME, Type: "normal", Power: 50, Accuracy: 100, PP: 1}
+)
+
+// stubRoller returns its rolls in order, then keeps repeating the last one.
+// Rolls are capped at n-1, so 0 is always the lowest outcome and 99 the highest:
+// 0 hits, lands a critical hit, rolls the lowest damage and passes every chance,
+// 99 hits without a critical hit, rolls full damage and fails every chance below 100.
+type stubRoller struct {
+	rolls []int
+}
+
+func rolls(values ...int) *stubRoller {
+	return &stubRoller{rolls: values}
+}
+
+func (r *stubRoller) IntN(n int) int {
+	roll := r.rolls[0]
+	if len(r.rolls) > 1 {
+		r.rolls = r.rolls[1:]
+	}
+	return min(roll, n-1)
+}
+
+// testEngine returns an engine over the test catalog plus the given moves, whose every
+// turn is rolled by r
+func testEngine(r Roller, moves ...Move) *Engine {
+	catalog := &Catalog{
+		Species:      map[int32]Species{},
+		Moves:        map[int32]Move{},
+		SpeciesMoves: map[int32][]int32{},
+	}
+	moves = append([]Move{tackle, quickAttack, knockOut, wildSwing, struggle}, moves...)
+	for _, move := range moves {
+		catalog.Moves[move.ID] = move
+	}
+	for _, species := range testSpecies {
+		catalog.Species[species.ID] = species
+		for _, move := range moves {
+			catalog.SpeciesMoves[species.ID] = append(catalog.SpeciesMoves[species.ID], move.ID)
+		}
+	}
+	return &Engine{
+		Catalog:   catalog,
+		NewRoller: func(int64, int32) Roller { return r },
+	}
+}
+
+// newBattle starts a battle on turn 1 between two teams of species, the first of each sent out
+func newBattle(t *testing.T, e *Engine, team1, team2 []int32) BattleState {
+	t.Helper()
+	newSide := func(playerID pgtype.UUID, speciesIDs []int32) Side {
+		side := Side{PlayerID: playerID, ActivePos: 1}
+		for i, speciesID := range speciesIDs {
+			poke, err := e.Catalog.NewPokemon(int32(i+1), speciesID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			side.Team = append(side.Team, poke)
+		}
+		return side
+	}
+	return BattleState{Seed: 1, Turn: 1, Player1: newSide(player1, team1), Player2: newSide(player2, team2)}
+}
+
+func attack(playerID pgtype.UUID, move Move) Action {
+	return Action{PlayerID: playerID, Type: ACTION_ATTACK, MoveID: move.ID}
+}
+
+func switchTo(playerID pgtype.UUID, position int32) Action {
+	return Action{PlayerID: playerID, Type: ACTION_SWITCH, Position: position}
+}
+
+// playTurn applies the actions of both players and returns the resolved turn
+func playTurn(t *testing.T, e *Engine, state BattleState, first, second Action) (BattleState, []Event) {
+	t.Helper()
+	state, _, err := e.Apply(state, first)
+	if err != nil {
+		t.Fatalf("first action: %v", err)
+	}
+	state, events, err := e.Apply(state, second)
+	if err != nil {
+		t.Fatalf("second action: %v", err)
+	}
+	return state, events
+}
+
+func eventTypes(events []Event) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// actors returns who caused each event of the given type, in order
+func actors(events []Event, eventType string) []pgtype.UUID {
+	ids := []pgtype.UUID{}
+	for _, event := range events {
+		if event.Type == eventType {
+			ids = append(ids, event.PlayerID)
+		}
+	}
+	return ids
+}
+
+func TestApplyWaitsForBothActions(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	waiting, events, err := e.Apply(state, attack(player1, tackle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 || waiting.Turn != 1 || len(waiting.Pending) != 1 {
+		t.Fatalf("first action should wait on turn 1, got %d events, turn %d, %d pending", len(events), waiting.Turn, len(waiting.Pending))
+	}
+	if len(state.Pending) != 0 {
+		t.Fatal("Apply changed the state it was given")
+	}
+	if _, _, err := e.Apply(waiting, attack(player1, tackle)); err == nil {
+		t.Fatal("a second action of the same player should be rejected")
+	}
+
+	resolved, events, err := e.Apply(waiting, attack(player2, tackle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Turn != 2 || resolved.Pending != nil {
+		t.Fatalf("turn should be resolved, got turn %d with %d pending", resolved.Turn, len(resolved.Pending))
+	}
+	if got := eventTypes(events); !slices.Equal(got, []string{EVENT_ATTACK, EVENT_ATTACK}) {
+		t.Fatalf("expected both attacks, got %v", got)
+	}
+	if waiting.Player2.Team[0].HP != 100 {
+		t.Fatal("resolving the turn changed the previous state")
+	}
+}
+
+func TestApplyOrdersByPriority(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	_, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, quickAttack))
+	if got := actors(events, EVENT_ATTACK); !slices.Equal(got, []pgtype.UUID{player2, player1}) {
+		t.Fatal("the slower pokemon should go first with a higher priority move")
+	}
+}
+
+func TestApplyOrdersBySpeed(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SLUGGISH}, []int32{SWIFT})
+
+	_, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if got := actors(events, EVENT_ATTACK); !slices.Equal(got, []pgtype.UUID{player2, player1}) {
+		t.Fatal("the faster pokemon should go first")
+	}
+}
+
+func TestApplySpeedTieIsRolled(t *testing.T) {
+	tests := []struct {
+		name  string
+		coin  int
+		order []pgtype.UUID
+	}{
+		{"keeps submission order", 0, []pgtype.UUID{player1, player2}},
+		{"swaps submission order", 1, []pgtype.UUID{player2, player1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(rolls(tt.coin, 99))
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SWIFT})
+
+			_, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+			if got := actors(events, EVENT_ATTACK); !slices.Equal(got, tt.order) {
+				t.Fatalf("coin %d gave the wrong order", tt.coin)
+			}
+		})
+	}
+}
+
+func TestApplySwitchesBeforeMoves(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SLUGGISH, SLUGGISH}, []int32{SWIFT})
+
+	next, events := playTurn(t, e, state, switchTo(player1, 2), attack(player2, quickAttack))
+	if got := eventTypes(events); !slices.Equal(got, []string{EVENT_SWITCH, EVENT_ATTACK}) {
+		t.Fatalf("expected the switch before the attack, got %v", got)
+	}
+	if next.Player1.ActivePos != 2 {
+		t.Fatalf("expected position 2 active, got %d", next.Player1.ActivePos)
+	}
+	if next.Player1.Team[0].HP != 100 || next.Player1.Team[1].HP >= 100 {
+		t.Fatal("the attack should hit the pokemon that was switched in")
+	}
+}
+
+func TestApplyFaintAndWinner(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	next, events := playTurn(t, e, state, attack(player1, knockOut), attack(player2, tackle))
+	if got := eventTypes(events); !slices.Equal(got, []string{EVENT_ATTACK, EVENT_FAINT}) {
+		t.Fatalf("the fainted pokemon should not move, got %v", got)
+	}
+	fainted := next.Player2.Active()
+	if !fainted.Fainted || fainted.HP != 0 {
+		t.Fatalf("expected a fainted pokemon with 0 HP, got %d HP", fainted.HP)
+	}
+	winnerID, ended := next.Winner()
+	if !ended || winnerID != player1 {
+		t.Fatal("player1 should win once player2 has no pokemon left")
+	}
+	if _, err := e.Validate(next, attack(player1, tackle)); err == nil {
+		t.Fatal("no action should be accepted once the battle is over")
+	}
+}
+
+func TestApplyFaintWithPokemonLeft(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH, SLUGGISH})
+
+	next, _ := playTurn(t, e, state, attack(player1, knockOut), attack(player2, tackle))
+	if _, ended := next.Winner(); ended {
+		t.Fatal("the battle should go on while player2 has pokemon left")
+	}
+	if !next.MustSwitch(player2) || next.MustSwitch(player1) {
+		t.Fatal("only player2 should have to replace its fainted pokemon")
+	}
+}
@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"testing"
+)
+
+// drainPP leaves the moves of the active pokemon of a side without PP, all of them when no move is given
+func drainPP(side *Side, moves ...Move) {
+	active := side.Active()
+	for i := range active.Moves {
+		if len(moves) == 0 {
+			active.Moves[i].PP = 0
+		}
+		for _, move := range moves {
+			if active.Moves[i].MoveID == move.ID {
+				active.Moves[i].PP = 0
+			}
+		}
+	}
+}
+
+func TestValidatePP(t *testing.T) {
+	tests := []struct {
+		name    string
+		drained []Move // nil drains every move
+		chosen  Move
+		want    int32 // move to perform, 0 when rejected
+	}{
+		{"move with PP left", []Move{quickAttack}, tackle, tackle.ID},
+		{"move out of PP", []Move{tackle}, tackle, 0},
+		{"every move out of PP", nil, tackle, struggle.ID},
+		{"any choice struggles", nil, knockOut, struggle.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(rolls(99))
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+			drainPP(&state.Player1, tt.drained...)
+
+			action, err := e.Validate(state, attack(player1, tt.chosen))
+			if tt.want == 0 {
+				if err == nil {
+					t.Fatal("a move out of PP should be rejected while others have PP left")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if action.MoveID != tt.want {
+				t.Fatalf("expected move %d, got %d", tt.want, action.MoveID)
+			}
+		})
+	}
+}
+
+func TestMovesSpendPP(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	next, _ := playTurn(t, e, state, attack(player1, tackle), attack(player2, quickAttack))
+	if pp := next.Player1.Active().Move(tackle.ID).PP; pp != tackle.PP-1 {
+		t.Fatalf("expected %d PP left, got %d", tackle.PP-1, pp)
+	}
+	if pp := next.Player2.Active().Move(quickAttack.ID).PP; pp != quickAttack.PP-1 {
+		t.Fatalf("expected %d PP left, got %d", quickAttack.PP-1, pp)
+	}
+}
+
+func TestStruggle(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	drainPP(&state.Player1)
+	putToSleep(&state.Player2)
+
+	next, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	hit, ok := findEvent(events, EVENT_ATTACK, player1)
+	if !ok || hit.MoveID != struggle.ID {
+		t.Fatalf("expected a Struggle, got %v", eventTypes(events))
+	}
+	recoil, ok := findEvent(events, EVENT_RECOIL, player1)
+	if !ok || recoil.Damage != 100/STRUGGLE_RECOIL_FRACTION {
+		t.Fatalf("Struggle should cost 1/%d of max HP, got %+v", STRUGGLE_RECOIL_FRACTION, recoil)
+	}
+	if next.Player1.Active().HP != 100-recoil.Damage {
+		t.Fatal("the recoil should hurt the user")
+	}
+	for _, move := range next.Player1.Active().Moves {
+		if move.PP != 0 {
+			t.Fatal("Struggle should not restore or spend PP")
+		}
+	}
+}
+
+func TestStruggleNeedsCatalogMove(t *testing.T) {
+	e := testEngine(rolls(99))
+	delete(e.Catalog.Moves, struggle.ID)
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	drainPP(&state.Player1)
+
+	if _, err := e.Validate(state, attack(player1, tackle)); err == nil {
+		t.Fatal("a catalog without Struggle can't replace moves out of PP")
+	}
+}
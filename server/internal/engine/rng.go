@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"math/rand/v2"
+)
+
+// Chance of landing a critical hit, as 1 in CRITICAL_HIT_ODDS
+const CRITICAL_HIT_ODDS = 24
+
+// Damage multiplier applied on a critical hit
+const CRITICAL_HIT_MULTIPLIER = 1.5
+
+// Damage rolls land between DAMAGE_ROLL_MIN% and 100% of the computed damage
+const DAMAGE_ROLL_MIN = 85
+
+// Roller is the random source used to resolve an action.
+// *rand.Rand satisfies it, tests can provide a fixed sequence instead.
+type Roller interface {
+	// IntN returns a number in [0, n)
+	IntN(n int) int
+}
+
+// RollerFactory builds the roller for a given battle seed and turn
+type RollerFactory func(seed int64, turn int32) Roller
+
+// SeededRoller derives a deterministic generator from the battle seed and the turn number,
+// so every turn can be replayed without storing the generator state.
+func SeededRoller(seed int64, turn int32) Roller {
+	return rand.New(rand.NewPCG(uint64(seed), uint64(turn)))
+}
+
+// NewBattleSeed returns a fresh seed for a battle
+func NewBattleSeed() int64 {
+	return rand.Int64()
+}
+
+// AttackRoll is the outcome of all random checks of a single attack
+type AttackRoll struct {
+	Hit         bool
+	CriticalHit bool
+	DamageRoll  int // Percentage applied to damage, DAMAGE_ROLL_MIN..100
+}
+
+// rollAttack resolves accuracy, critical hit and damage variance for a move
+func rollAttack(r Roller, accuracy int32) AttackRoll {
+	if r.IntN(100) >= int(accuracy) {
+		return AttackRoll{Hit: false}
+	}
+	return AttackRoll{
+		Hit:         true,
+		CriticalHit: r.IntN(CRITICAL_HIT_ODDS) == 0,
+		DamageRoll:  DAMAGE_ROLL_MIN + r.IntN(100-DAMAGE_ROLL_MIN+1),
+	}
+}
+
+// multiplier returns the damage modifier produced by the roll
+func (a AttackRoll) multiplier() float64 {
+	if !a.Hit {
+		return 0
+	}
+	m := float64(a.DamageRoll) / 100
+	if a.CriticalHit {
+		m *= CRITICAL_HIT_MULTIPLIER
+	}
+	return m
+}
+
+// MedianRoller always lands in the middle of the range: moves more than 50% accurate hit,
+// there are no critical hits and damage rolls halfway between DAMAGE_ROLL_MIN and 100.
+// Bots use it to play turns out without luck getting in the way.
+type MedianRoller struct{}
+
+// IntN returns n/2
+func (MedianRoller) IntN(n int) int {
+	return n / 2
+}
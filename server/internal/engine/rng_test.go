@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRollAttack(t *testing.T) {
+	tests := []struct {
+		name     string
+		roller   *stubRoller
+		accuracy int32
+		want     AttackRoll
+	}{
+		{"misses at the accuracy", rolls(50), 50, AttackRoll{Hit: false}},
+		{"hits below the accuracy", rolls(49, 1, 0), 50, AttackRoll{Hit: true, DamageRoll: DAMAGE_ROLL_MIN}},
+		{"always hits at 100", rolls(99, 1, 0), 100, AttackRoll{Hit: true, DamageRoll: DAMAGE_ROLL_MIN}},
+		{"critical hit", rolls(0, 0, 0), 100, AttackRoll{Hit: true, CriticalHit: true, DamageRoll: DAMAGE_ROLL_MIN}},
+		{"full damage", rolls(0, 1, 99), 100, AttackRoll{Hit: true, DamageRoll: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollAttack(tt.roller, tt.accuracy); got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAttackRollMultiplier(t *testing.T) {
+	tests := []struct {
+		roll AttackRoll
+		want float64
+	}{
+		{AttackRoll{Hit: false, DamageRoll: 100}, 0},
+		{AttackRoll{Hit: true, DamageRoll: DAMAGE_ROLL_MIN}, 0.85},
+		{AttackRoll{Hit: true, DamageRoll: 100}, 1},
+		{AttackRoll{Hit: true, CriticalHit: true, DamageRoll: 100}, CRITICAL_HIT_MULTIPLIER},
+	}
+	for _, tt := range tests {
+		if got := tt.roll.multiplier(); got != tt.want {
+			t.Errorf("%+v: expected x%.2f, got x%.2f", tt.roll, tt.want, got)
+		}
+	}
+}
+
+func TestMissEvent(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	next, events := playTurn(t, e, state, attack(player1, wildSwing), attack(player2, tackle))
+	miss, ok := findEvent(events, EVENT_MISS, player1)
+	if !ok || miss.MoveID != wildSwing.ID || !strings.HasSuffix(miss.Message, "But it missed!") {
+		t.Fatalf("expected Wild Swing to miss, got %v", eventTypes(events))
+	}
+	if next.Player2.Active().HP != 100 {
+		t.Fatal("a miss should deal no damage")
+	}
+	if next.Player1.Active().Move(wildSwing.ID).PP != wildSwing.PP-1 {
+		t.Fatal("a miss still spends PP")
+	}
+}
+
+func TestCriticalHit(t *testing.T) {
+	e := testEngine(rolls(0))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	want, _ := e.damage(state.Player1.Active(), state.Player2.Active(), knockOut, 0.85*CRITICAL_HIT_MULTIPLIER)
+
+	// Sluggish survives the lowest critical Knock Out to attack back
+	state.Player2.Team[0].HP = want + 1
+	_, events := playTurn(t, e, state, attack(player1, knockOut), attack(player2, tackle))
+	hit, _ := findEvent(events, EVENT_ATTACK, player1)
+	if !hit.CriticalHit || !strings.Contains(hit.Message, "A critical hit!") {
+		t.Fatalf("a crit roll of 0 should land a critical hit, got %+v", hit)
+	}
+	if hit.Damage != want {
+		t.Fatalf("expected %d damage, got %d", want, hit.Damage)
+	}
+}
+
+func TestDamageRollBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		roller *stubRoller
+		roll   float64
+	}{
+		{"lowest roll", rolls(0, 1, 0), 0.85},
+		{"highest roll", rolls(0, 1, 99), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(tt.roller)
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+			want, _ := e.damage(state.Player1.Active(), state.Player2.Active(), knockOut, tt.roll)
+			state.Player2.Team[0].HP = 1000
+
+			_, events := playTurn(t, e, state, attack(player1, knockOut), attack(player2, tackle))
+			hit, _ := findEvent(events, EVENT_ATTACK, player1)
+			if hit.CriticalHit || hit.Damage != want {
+				t.Fatalf("expected %d damage, got %d", want, hit.Damage)
+			}
+		})
+	}
+}
+
+func TestSeededRollerReplays(t *testing.T) {
+	draw := func(r Roller) []int {
+		values := make([]int, 10)
+		for i := range values {
+			values[i] = r.IntN(1000)
+		}
+		return values
+	}
+
+	first := draw(SeededRoller(42, 3))
+	if again := draw(SeededRoller(42, 3)); !slices.Equal(first, again) {
+		t.Fatal("the same seed and turn should roll the same numbers")
+	}
+	if next := draw(SeededRoller(42, 4)); slices.Equal(first, next) {
+		t.Fatal("each turn should roll its own numbers")
+	}
+}
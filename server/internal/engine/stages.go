@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Stats that can be raised or lowered in battle
+const (
+	STAT_ATTACK  = "attack"
+	STAT_DEFENSE = "defense"
+	STAT_SPEED   = "speed"
+)
+
+// Stats lists every stat with a stage, in the order they are shown
+var Stats = []string{STAT_ATTACK, STAT_DEFENSE, STAT_SPEED}
+
+// Stages of a stat go from STAGE_MIN to STAGE_MAX, 0 leaves it untouched
+const (
+	STAGE_MIN = -6
+	STAGE_MAX = 6
+)
+
+// Kinds of events caused by stat stages
+const (
+	EVENT_STAT = "stat" // A stat of a pokemon rose or fell
+)
+
+// stageMultiplier is how much a stage scales its stat: +1 is x1.5, +6 is x4, -1 is x0.67 and -6 is x0.25
+func stageMultiplier(stage int32) float64 {
+	if stage >= 0 {
+		return float64(2+stage) / 2
+	}
+	return 2 / float64(2-stage)
+}
+
+// stat returns a stat of a pokemon with its stage applied
+func (e *Engine) stat(poke *Pokemon, stat string) int32 {
+	species := e.Catalog.Species[poke.SpeciesID]
+	var base int32
+	switch stat {
+	case STAT_ATTACK:
+		base = species.BaseAttack
+	case STAT_DEFENSE:
+		base = species.BaseDefense
+	case STAT_SPEED:
+		base = species.BaseSpeed
+	}
+	return max(int32(float64(base)*stageMultiplier(poke.Stages[stat])), 1)
+}
+
+// changeStage raises (or lowers, when change is negative) a stat of a pokemon, within STAGE_MIN and STAGE_MAX
+func (e *Engine) changeStage(playerID pgtype.UUID, poke *Pokemon, stat string, change int32) Event {
+	name := e.Catalog.Species[poke.SpeciesID].Name
+	stage := poke.Stages[stat]
+	next := min(max(stage+change, STAGE_MIN), STAGE_MAX)
+
+	var message string
+	switch {
+	case next == stage && change > 0:
+		message = fmt.Sprintf("%s's %s won't go any higher!", name, stat)
+	case next == stage:
+		message = fmt.Sprintf("%s's %s won't go any lower!", name, stat)
+	case next-stage >= 2:
+		message = fmt.Sprintf("%s's %s sharply rose!", name, stat)
+	case next-stage > 0:
+		message = fmt.Sprintf("%s's %s rose!", name, stat)
+	case next-stage <= -2:
+		message = fmt.Sprintf("%s's %s harshly fell!", name, stat)
+	default:
+		message = fmt.Sprintf("%s's %s fell!", name, stat)
+	}
+
+	if next != stage {
+		if poke.Stages == nil {
+			poke.Stages = map[string]int32{}
+		}
+		poke.Stages[stat] = next
+	}
+	return Event{
+		Type:        EVENT_STAT,
+		PlayerID:    playerID,
+		Position:    poke.Position,
+		RemainingHP: poke.HP,
+		Stat:        stat,
+		StatChange:  next - stage,
+		Message:     message,
+	}
+}
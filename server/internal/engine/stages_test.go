@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func statMove(id int32, name, target, stat string, change int32) Move {
+	return Move{ID: id, Name: name, Type: "normal", Accuracy: 100, PP: 20, Effects: []Effect{
+		{Kind: EFFECT_STAT, Target: target, Chance: 100, Stat: stat, Magnitude: change},
+	}}
+}
+
+var (
+	swordsDance = statMove(30, "Swords Dance", EFFECT_TARGET_USER, STAT_ATTACK, 2)
+	growl       = statMove(31, "Growl", EFFECT_TARGET_TARGET, STAT_ATTACK, -1)
+	agility     = statMove(32, "Agility", EFFECT_TARGET_USER, STAT_SPEED, 2)
+)
+
+func TestStageMultiplier(t *testing.T) {
+	tests := []struct {
+		stage      int32
+		multiplier float64
+	}{
+		{0, 1},
+		{1, 1.5},
+		{2, 2},
+		{STAGE_MAX, 4},
+		{-1, 0.67},
+		{-2, 0.5},
+		{STAGE_MIN, 0.25},
+	}
+	for _, tt := range tests {
+		if got := stageMultiplier(tt.stage); math.Abs(got-tt.multiplier) > 0.01 {
+			t.Errorf("stage %d: expected x%.2f, got x%.2f", tt.stage, tt.multiplier, got)
+		}
+	}
+}
+
+func TestStageMoves(t *testing.T) {
+	e := testEngine(rolls(99), swordsDance, growl)
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+	// Swift raises its own attack, then Sluggish lowers it
+	next, events := playTurn(t, e, state, attack(player1, swordsDance), attack(player2, growl))
+	if got := actors(events, EVENT_STAT); !slices.Equal(got, []pgtype.UUID{player1, player1}) {
+		t.Fatalf("both changes should be about Swift, got %v", eventTypes(events))
+	}
+	var changes []int32
+	for _, event := range events {
+		if event.Type == EVENT_STAT {
+			changes = append(changes, event.StatChange)
+		}
+	}
+	if !slices.Equal(changes, []int32{2, -1}) {
+		t.Fatalf("expected +2 then -1, got %v", changes)
+	}
+	if stage := next.Player1.Active().Stages[STAT_ATTACK]; stage != 1 {
+		t.Fatalf("expected attack at +1, got %d", stage)
+	}
+	if len(next.Player2.Active().Stages) != 0 {
+		t.Fatal("the opponent's stages should be untouched")
+	}
+}
+
+func TestStagesAreClamped(t *testing.T) {
+	tests := []struct {
+		name    string
+		stage   int32
+		change  int32
+		next    int32
+		message string
+	}{
+		{"sharply rises", 0, 2, 2, "Swift's attack sharply rose!"},
+		{"rises up to the max", STAGE_MAX - 1, 2, STAGE_MAX, "Swift's attack rose!"},
+		{"won't go above the max", STAGE_MAX, 1, STAGE_MAX, "Swift's attack won't go any higher!"},
+		{"harshly falls", 0, -2, -2, "Swift's attack harshly fell!"},
+		{"falls down to the min", STAGE_MIN + 1, -2, STAGE_MIN, "Swift's attack fell!"},
+		{"won't go below the min", STAGE_MIN, -1, STAGE_MIN, "Swift's attack won't go any lower!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(rolls(99))
+			poke := Pokemon{SpeciesID: SWIFT, Position: 1, HP: 100, Stages: map[string]int32{STAT_ATTACK: tt.stage}}
+
+			event := e.changeStage(player1, &poke, STAT_ATTACK, tt.change)
+			if event.Type != EVENT_STAT || event.Stat != STAT_ATTACK || event.Message != tt.message {
+				t.Fatalf("got %+v", event)
+			}
+			if event.StatChange != tt.next-tt.stage || poke.Stages[STAT_ATTACK] != tt.next {
+				t.Fatalf("expected stage %d, got %d changed by %d", tt.next, poke.Stages[STAT_ATTACK], event.StatChange)
+			}
+		})
+	}
+}
+
+func TestStagesChangeDamage(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	attacker, defender := state.Player1.Active(), state.Player2.Active()
+
+	neutral, _ := e.damage(attacker, defender, knockOut, 1)
+	attacker.Stages = map[string]int32{STAT_ATTACK: 2}
+	boosted, _ := e.damage(attacker, defender, knockOut, 1)
+	defender.Stages = map[string]int32{STAT_DEFENSE: 2}
+	even, _ := e.damage(attacker, defender, knockOut, 1)
+
+	if boosted <= neutral {
+		t.Fatalf("raised attack should deal more, got %d against %d", boosted, neutral)
+	}
+	if even != neutral {
+		t.Fatalf("raised defense should cancel raised attack, got %d against %d", even, neutral)
+	}
+}
+
+func TestStagesChangeSpeedOrder(t *testing.T) {
+	e := testEngine(rolls(99), agility)
+	state := newBattle(t, e, []int32{SLUGGISH}, []int32{SWIFT})
+
+	// Sluggish goes from 30 to 120 speed, past Swift's 90
+	for range 3 {
+		state, _ = playTurn(t, e, state, attack(player1, agility), attack(player2, tackle))
+	}
+	if speed := e.speed(state.Player1.Active()); speed != 120 {
+		t.Fatalf("expected 120 speed at +6, got %d", speed)
+	}
+	_, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if got := actors(events, EVENT_ATTACK); !slices.Equal(got, []pgtype.UUID{player1, player2}) {
+		t.Fatal("the boosted pokemon should outspeed")
+	}
+}
+
+func TestStagesResetOnSwitch(t *testing.T) {
+	e := testEngine(rolls(99), swordsDance)
+	state := newBattle(t, e, []int32{SWIFT, SWIFT}, []int32{SLUGGISH})
+
+	state, _ = playTurn(t, e, state, attack(player1, swordsDance), attack(player2, tackle))
+	if state.Player1.Active().Stages[STAT_ATTACK] != 2 {
+		t.Fatal("the attack should be raised before switching")
+	}
+	state, _ = playTurn(t, e, state, switchTo(player1, 2), attack(player2, tackle))
+	state, _ = playTurn(t, e, state, switchTo(player1, 1), attack(player2, tackle))
+	if stages := state.Player1.Active().Stages; len(stages) != 0 {
+		t.Fatalf("switching out should reset the stages, got %v", stages)
+	}
+}
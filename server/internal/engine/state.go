@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Kinds of actions a player can choose for a turn
+const (
+	ACTION_ATTACK = "attack"
+	ACTION_SWITCH = "switch"
+)
+
+// Switching always happens before any move is used
+const SWITCH_PRIORITY = 7
+
+// Kinds of events produced while resolving a turn
+const (
+	EVENT_SWITCH = "switch"
+	EVENT_ATTACK = "attack"
+	EVENT_MISS   = "miss"
+	EVENT_RECOIL = "recoil"
+	EVENT_FAINT  = "faint"
+	EVENT_MOVE   = "move" // A move without power was used, its effects follow
+)
+
+// Action is the action a player chose for a turn
+type Action struct {
+	PlayerID pgtype.UUID
+	Type     string // ACTION_ATTACK or ACTION_SWITCH
+	MoveID   int32  // Move to use on ACTION_ATTACK
+	Position int32  // Team position to send out on ACTION_SWITCH
+}
+
+// Event is a single thing that happened while resolving a turn
+type Event struct {
+	Type          string
+	PlayerID      pgtype.UUID // Owner of the pokemon the event is about
+	Position      int32
+	MoveID        int32
+	MoveName      string
+	Damage        int32
+	RemainingHP   int32
+	Effectiveness string
+	CriticalHit   bool
+	Status        string // Status the event is about, see STATUS_*
+	Stat          string // Stat that changed on EVENT_STAT, see STAT_*
+	StatChange    int32  // Stages the stat rose, negative when it fell
+	Message       string
+}
+
+// MoveSlot is a move known by a team member and the power points it has left
+type MoveSlot struct {
+	MoveID int32
+	Name   string
+	PP     int32
+	MaxPP  int32
+}
+
+// Pokemon is a team member during a battle
+type Pokemon struct {
+	Position    int32
+	SpeciesID   int32
+	HP          int32
+	Fainted     bool
+	Status      string           // One of STATUS_*, empty while healthy
+	StatusTurns int32            // Turns left to sleep
+	Recharging  bool             // Sits out its next turn after a recharge move
+	Flinched    bool             // Loses its move for the rest of the turn
+	Stages      map[string]int32 // Stage of each stat, see STAT_*. Missing stats are at 0
+	Moves       []MoveSlot
+}
+
+// Side is one player of a battle
+type Side struct {
+	PlayerID  pgtype.UUID
+	Team      []Pokemon // Ordered by position
+	ActivePos int32
+}
+
+// BattleState is everything the rules need to know about a battle.
+// It is a value: Apply never changes the state it is given.
+type BattleState struct {
+	Seed    int64 // Random source of every turn, see SeededRoller
+	Turn    int32
+	Player1 Side
+	Player2 Side
+	Pending []Action // Actions chosen for Turn so far, in the order they were submitted
+}
+
+// Clone returns a copy of the state that shares no memory with it
+func (st BattleState) Clone() BattleState {
+	st.Player1 = st.Player1.clone()
+	st.Player2 = st.Player2.clone()
+	st.Pending = append([]Action(nil), st.Pending...)
+	return st
+}
+
+func (side Side) clone() Side {
+	team := make([]Pokemon, len(side.Team))
+	for i, poke := range side.Team {
+		team[i] = poke
+		team[i].Moves = append([]MoveSlot(nil), poke.Moves...)
+		team[i].Stages = maps.Clone(poke.Stages)
+	}
+	side.Team = team
+	return side
+}
+
+// Sides returns the side of the given player and the side of its opponent
+func (st *BattleState) Sides(playerID pgtype.UUID) (*Side, *Side, error) {
+	switch playerID {
+	case st.Player1.PlayerID:
+		return &st.Player1, &st.Player2, nil
+	case st.Player2.PlayerID:
+		return &st.Player2, &st.Player1, nil
+	default:
+		return nil, nil, fmt.Errorf("you are not part of this battle")
+	}
+}
+
+// Winner returns the player left with pokemon standing once the other one has none
+func (st BattleState) Winner() (pgtype.UUID, bool) {
+	if st.Player1.Defeated() {
+		return st.Player2.PlayerID, true
+	}
+	if st.Player2.Defeated() {
+		return st.Player1.PlayerID, true
+	}
+	return pgtype.UUID{}, false
+}
+
+// MustSwitch reports whether the player has to replace a fainted active pokemon before
+// the battle goes on. The replacement is chosen with an ACTION_SWITCH and takes no turn.
+func (st BattleState) MustSwitch(playerID pgtype.UUID) bool {
+	side, _, err := st.Sides(playerID)
+	return err == nil && side.mustSwitch()
+}
+
+// Switching reports whether either player has a fainted active pokemon to replace
+func (st BattleState) Switching() bool {
+	return st.Player1.mustSwitch() || st.Player2.mustSwitch()
+}
+
+// Active returns the pokemon on the field, nil if the side has none
+func (side *Side) Active() *Pokemon {
+	return side.Pokemon(side.ActivePos)
+}
+
+// Pokemon returns the team member at the given slot, or nil if the slot is empty
+func (side *Side) Pokemon(position int32) *Pokemon {
+	for i := range side.Team {
+		if side.Team[i].Position == position {
+			return &side.Team[i]
+		}
+	}
+	return nil
+}
+
+// Defeated reports whether every pokemon of the side fainted
+func (side *Side) Defeated() bool {
+	for _, poke := range side.Team {
+		if !poke.Fainted {
+			return false
+		}
+	}
+	return true
+}
+
+// mustSwitch reports whether the active pokemon fainted and there is another one to send out
+func (side *Side) mustSwitch() bool {
+	active := side.Active()
+	return active != nil && active.Fainted && side.nextAvailable() != nil
+}
+
+// nextAvailable finds the first pokemon able to battle that is not the active one
+func (side *Side) nextAvailable() *Pokemon {
+	for i := range side.Team {
+		poke := &side.Team[i]
+		if poke.Position != side.ActivePos && poke.HP > 0 && !poke.Fainted {
+			return poke
+		}
+	}
+	return nil
+}
+
+// Move returns the slot of a move the pokemon knows, or nil
+func (poke *Pokemon) Move(moveID int32) *MoveSlot {
+	for i := range poke.Moves {
+		if poke.Moves[i].MoveID == moveID {
+			return &poke.Moves[i]
+		}
+	}
+	return nil
+}
+
+// hasPPLeft reports whether any move of the pokemon can still be used
+func (poke *Pokemon) hasPPLeft() bool {
+	for _, move := range poke.Moves {
+		if move.PP > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDamage lowers the HP of a pokemon, marking it as fainted when it reaches 0
+func (poke *Pokemon) applyDamage(damage int32) {
+	poke.HP -= damage
+	if poke.HP <= 0 {
+		poke.HP = 0
+		poke.Fainted = true
+	}
+}
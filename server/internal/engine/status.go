@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Non-volatile status conditions, a pokemon has at most one and keeps it when switched out
+const (
+	STATUS_BURN      = "burn"
+	STATUS_PARALYSIS = "paralysis"
+	STATUS_POISON    = "poison"
+	STATUS_SLEEP     = "sleep"
+	STATUS_FREEZE    = "freeze"
+)
+
+// Kinds of events caused by status conditions
+const (
+	EVENT_STATUS        = "status"        // A pokemon got a status
+	EVENT_STATUS_DAMAGE = "status_damage" // A burned or poisoned pokemon lost HP at the end of the turn
+	EVENT_CANT_MOVE     = "cant_move"     // A pokemon lost its move to sleep, freeze or paralysis
+	EVENT_CURE          = "cure"          // A pokemon woke up or thawed out
+)
+
+// Burned and poisoned pokemon lose 1/N of their max HP at the end of every turn
+const (
+	BURN_DAMAGE_FRACTION   = 16
+	POISON_DAMAGE_FRACTION = 8
+)
+
+// Burned pokemon deal less damage, paralyzed ones are slower
+const (
+	BURN_ATTACK_MULTIPLIER     = 0.5
+	PARALYSIS_SPEED_MULTIPLIER = 0.5
+)
+
+// Chances in percent of a paralyzed pokemon not moving and of a frozen one thawing out
+const (
+	PARALYSIS_SKIP_CHANCE = 25
+	FREEZE_THAW_CHANCE    = 20
+)
+
+// A pokemon put to sleep skips between SLEEP_MIN_TURNS and SLEEP_MAX_TURNS turns
+const (
+	SLEEP_MIN_TURNS = 1
+	SLEEP_MAX_TURNS = 3
+)
+
+// statusImmunities lists the types that can never get each status
+var statusImmunities = map[string][]string{
+	STATUS_BURN:      {"fire"},
+	STATUS_PARALYSIS: {"electric"},
+	STATUS_POISON:    {"poison", "steel"},
+	STATUS_FREEZE:    {"ice"},
+}
+
+var statusMessages = map[string]string{
+	STATUS_BURN:      "%s was burned!",
+	STATUS_PARALYSIS: "%s is paralyzed! It may be unable to move!",
+	STATUS_POISON:    "%s was poisoned!",
+	STATUS_SLEEP:     "%s fell asleep!",
+	STATUS_FREEZE:    "%s was frozen solid!",
+}
+
+// speed returns the speed a pokemon acts with this turn
+func (e *Engine) speed(poke *Pokemon) int32 {
+	speed := e.stat(poke, STAT_SPEED)
+	if poke.Status == STATUS_PARALYSIS {
+		speed = int32(float64(speed) * PARALYSIS_SPEED_MULTIPLIER)
+	}
+	return speed
+}
+
+// attackMultiplier scales the damage a pokemon deals because of its status
+func attackMultiplier(poke *Pokemon) float64 {
+	if poke.Status == STATUS_BURN {
+		return BURN_ATTACK_MULTIPLIER
+	}
+	return 1
+}
+
+// canMove checks whether the status of a pokemon lets it use its move this turn.
+// Sleep and freeze wear off here, before the pokemon acts.
+func (e *Engine) canMove(r Roller, playerID pgtype.UUID, poke *Pokemon) (bool, []Event) {
+	name := e.Catalog.Species[poke.SpeciesID].Name
+	switch poke.Status {
+	case STATUS_SLEEP:
+		if poke.StatusTurns > 0 {
+			poke.StatusTurns--
+			return false, []Event{statusEvent(EVENT_CANT_MOVE, playerID, poke, fmt.Sprintf("%s is fast asleep.", name))}
+		}
+		cure := statusEvent(EVENT_CURE, playerID, poke, fmt.Sprintf("%s woke up!", name))
+		poke.Status = ""
+		return true, []Event{cure}
+
+	case STATUS_FREEZE:
+		if r.IntN(100) < FREEZE_THAW_CHANCE {
+			cure := statusEvent(EVENT_CURE, playerID, poke, fmt.Sprintf("%s thawed out!", name))
+			poke.Status = ""
+			return true, []Event{cure}
+		}
+		return false, []Event{statusEvent(EVENT_CANT_MOVE, playerID, poke, fmt.Sprintf("%s is frozen solid!", name))}
+
+	case STATUS_PARALYSIS:
+		if r.IntN(100) < PARALYSIS_SKIP_CHANCE {
+			return false, []Event{statusEvent(EVENT_CANT_MOVE, playerID, poke, fmt.Sprintf("%s is paralyzed! It can't move!", name))}
+		}
+	}
+	return true, nil
+}
+
+// tryInflict rolls a status effect on target, returning the event if it stuck
+func (e *Engine) tryInflict(r Roller, playerID pgtype.UUID, target *Pokemon, status string, chance int32) []Event {
+	if status == "" || chance <= 0 || target.Status != "" || target.Fainted {
+		return nil
+	}
+	species := e.Catalog.Species[target.SpeciesID]
+	immune := statusImmunities[status]
+	if slices.Contains(immune, species.Type1) || slices.Contains(immune, species.Type2) {
+		return nil
+	}
+	if !rollChance(r, chance) {
+		return nil
+	}
+
+	target.Status = status
+	target.StatusTurns = 0
+	if status == STATUS_SLEEP {
+		target.StatusTurns = int32(SLEEP_MIN_TURNS + r.IntN(SLEEP_MAX_TURNS-SLEEP_MIN_TURNS+1))
+	}
+	return []Event{statusEvent(EVENT_STATUS, playerID, target, fmt.Sprintf(statusMessages[status], species.Name))}
+}
+
+// statusDamage hurts the active pokemon of a side if it is burned or poisoned, at the end of the turn
+func (e *Engine) statusDamage(side *Side) []Event {
+	poke := side.Active()
+	if poke == nil || poke.Fainted {
+		return nil
+	}
+
+	species := e.Catalog.Species[poke.SpeciesID]
+	var damage int32
+	var message string
+	switch poke.Status {
+	case STATUS_BURN:
+		damage = max(species.BaseHP/BURN_DAMAGE_FRACTION, 1)
+		message = "%s is hurt by its burn!"
+	case STATUS_POISON:
+		damage = max(species.BaseHP/POISON_DAMAGE_FRACTION, 1)
+		message = "%s is hurt by poison!"
+	default:
+		return nil
+	}
+
+	poke.applyDamage(damage)
+	event := statusEvent(EVENT_STATUS_DAMAGE, side.PlayerID, poke, fmt.Sprintf(message, species.Name))
+	event.Damage = damage
+	events := []Event{event}
+	if poke.Fainted {
+		events = append(events, faintEvent(side.PlayerID, poke, species))
+	}
+	return events
+}
+
+func statusEvent(eventType string, playerID pgtype.UUID, poke *Pokemon, message string) Event {
+	return Event{
+		Type:        eventType,
+		PlayerID:    playerID,
+		Position:    poke.Position,
+		RemainingHP: poke.HP,
+		Status:      poke.Status,
+		Message:     message,
+	}
+}
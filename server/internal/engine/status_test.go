@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func statusMove(id int32, name, status string) Move {
+	return Move{ID: id, Name: name, Type: "normal", Accuracy: 100, PP: 10, Effects: []Effect{
+		{Kind: EFFECT_STATUS, Target: EFFECT_TARGET_TARGET, Chance: 100, Status: status},
+	}}
+}
+
+var (
+	willOWisp   = statusMove(10, "Will-O-Wisp", STATUS_BURN)
+	thunderWave = statusMove(11, "Thunder Wave", STATUS_PARALYSIS)
+	toxic       = statusMove(12, "Toxic", STATUS_POISON)
+	hypnosis    = statusMove(13, "Hypnosis", STATUS_SLEEP)
+	freezeRay   = statusMove(14, "Freeze Ray", STATUS_FREEZE)
+)
+
+// findEvent returns the first event of the given type about the player's pokemon
+func findEvent(events []Event, eventType string, playerID pgtype.UUID) (Event, bool) {
+	for _, event := range events {
+		if event.Type == eventType && event.PlayerID == playerID {
+			return event, true
+		}
+	}
+	return Event{}, false
+}
+
+func TestStatusDamageAtEndOfTurn(t *testing.T) {
+	tests := []struct {
+		status string
+		damage int32
+	}{
+		{STATUS_BURN, 100 / BURN_DAMAGE_FRACTION},
+		{STATUS_POISON, 100 / POISON_DAMAGE_FRACTION},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			e := testEngine(rolls(99))
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+			state.Player2.Team[0].Status = tt.status
+
+			next, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+			if events[len(events)-1].Type != EVENT_STATUS_DAMAGE {
+				t.Fatalf("status damage should come last, got %v", eventTypes(events))
+			}
+			event, _ := findEvent(events, EVENT_STATUS_DAMAGE, player2)
+			if event.Damage != tt.damage {
+				t.Fatalf("expected %d damage, got %d", tt.damage, event.Damage)
+			}
+			if _, hurt := findEvent(events, EVENT_STATUS_DAMAGE, player1); hurt {
+				t.Fatal("a healthy pokemon should not be hurt")
+			}
+			if next.Player2.Active().HP != event.RemainingHP {
+				t.Fatal("the event should carry the HP left")
+			}
+		})
+	}
+}
+
+func TestBurnHalvesDamage(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	attacker, defender := state.Player1.Active(), state.Player2.Active()
+
+	healthy, _ := e.damage(attacker, defender, tackle, 1)
+	attacker.Status = STATUS_BURN
+	burned, _ := e.damage(attacker, defender, tackle, 1)
+	if burned != int32(float64(healthy)*BURN_ATTACK_MULTIPLIER) {
+		t.Fatalf("burned attacker dealt %d, healthy %d", burned, healthy)
+	}
+}
+
+func TestParalysisSkipsTurn(t *testing.T) {
+	e := testEngine(rolls(0))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	state.Player1.Team[0].Status = STATUS_PARALYSIS
+
+	_, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if _, lost := findEvent(events, EVENT_CANT_MOVE, player1); !lost {
+		t.Fatalf("a roll below %d%% should keep it from moving, got %v", PARALYSIS_SKIP_CHANCE, eventTypes(events))
+	}
+	if got := actors(events, EVENT_ATTACK); !slices.Equal(got, []pgtype.UUID{player2}) {
+		t.Fatal("only the opponent should attack")
+	}
+}
+
+func TestParalysisHalvesSpeed(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{BLAZE})
+	state.Player1.Team[0].Status = STATUS_PARALYSIS
+
+	if speed := e.speed(state.Player1.Active()); speed != 45 {
+		t.Fatalf("expected half of 90 speed, got %d", speed)
+	}
+	_, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if got := actors(events, EVENT_ATTACK); !slices.Equal(got, []pgtype.UUID{player2, player1}) {
+		t.Fatal("the paralyzed pokemon should be outsped")
+	}
+}
+
+func TestSleepCountsDownThenWakes(t *testing.T) {
+	e := testEngine(rolls(99))
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	state.Player1.Team[0].Status = STATUS_SLEEP
+	state.Player1.Team[0].StatusTurns = 2
+
+	for turn := range 2 {
+		var events []Event
+		state, events = playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+		if _, lost := findEvent(events, EVENT_CANT_MOVE, player1); !lost {
+			t.Fatalf("should still sleep on turn %d", turn+1)
+		}
+	}
+	if state.Player1.Active().StatusTurns != 0 {
+		t.Fatal("sleep turns should be used up")
+	}
+
+	state, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+	if got := eventTypes(events); !slices.Equal(got[:2], []string{EVENT_CURE, EVENT_ATTACK}) {
+		t.Fatalf("should wake up and attack, got %v", got)
+	}
+	if state.Player1.Active().Status != "" {
+		t.Fatal("the pokemon should be healthy after waking up")
+	}
+}
+
+func TestSleepLasts(t *testing.T) {
+	tests := []struct {
+		roll  int
+		turns int32
+	}{
+		{0, SLEEP_MIN_TURNS},
+		{99, SLEEP_MAX_TURNS},
+	}
+	for _, tt := range tests {
+		e := testEngine(rolls(tt.roll), hypnosis)
+		state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+
+		// The slower target already sleeps through its own move this turn
+		state, events := playTurn(t, e, state, attack(player1, hypnosis), attack(player2, tackle))
+		if _, lost := findEvent(events, EVENT_CANT_MOVE, player2); !lost {
+			t.Fatalf("roll %d: the target should fall asleep before moving", tt.roll)
+		}
+		if poke := state.Player2.Active(); poke.Status != STATUS_SLEEP || poke.StatusTurns != tt.turns-1 {
+			t.Fatalf("roll %d: expected %d turns of sleep, got %q for %d more", tt.roll, tt.turns, poke.Status, poke.StatusTurns)
+		}
+	}
+}
+
+func TestFreezeThaws(t *testing.T) {
+	tests := []struct {
+		name   string
+		roll   int
+		thawed bool
+	}{
+		{"stays frozen", 99, false},
+		{"thaws out", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := testEngine(rolls(tt.roll))
+			state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+			state.Player1.Team[0].Status = STATUS_FREEZE
+
+			next, events := playTurn(t, e, state, attack(player1, tackle), attack(player2, tackle))
+			_, cured := findEvent(events, EVENT_CURE, player1)
+			_, lost := findEvent(events, EVENT_CANT_MOVE, player1)
+			if cured != tt.thawed || lost == tt.thawed {
+				t.Fatalf("got %v", eventTypes(events))
+			}
+			if (next.Player1.Active().Status == "") != tt.thawed {
+				t.Fatal("status should only be cleared when thawed")
+			}
+		})
+	}
+}
+
+func TestStatusImmunities(t *testing.T) {
+	tests := []struct {
+		move   Move
+		immune int32
+		status string
+	}{
+		{willOWisp, BLAZE, STATUS_BURN},
+		{thunderWave, VOLT, STATUS_PARALYSIS},
+		{toxic, VENOM, STATUS_POISON},
+		{freezeRay, GLACIER, STATUS_FREEZE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			e := testEngine(rolls(99), tt.move)
+			state := newBattle(t, e, []int32{SWIFT}, []int32{tt.immune})
+
+			next, events := playTurn(t, e, state, attack(player1, tt.move), attack(player2, tackle))
+			if _, got := findEvent(events, EVENT_STATUS, player2); got || next.Player2.Active().Status != "" {
+				t.Fatalf("%s should be immune to %s", e.Catalog.Species[tt.immune].Name, tt.status)
+			}
+
+			state = newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+			next, events = playTurn(t, e, state, attack(player1, tt.move), attack(player2, tackle))
+			event, got := findEvent(events, EVENT_STATUS, player2)
+			if !got || event.Status != tt.status || next.Player2.Active().Status != tt.status {
+				t.Fatalf("a normal type should get %s", tt.status)
+			}
+		})
+	}
+}
+
+func TestStatusDoesNotStack(t *testing.T) {
+	e := testEngine(rolls(99), willOWisp)
+	state := newBattle(t, e, []int32{SWIFT}, []int32{SLUGGISH})
+	state.Player2.Team[0].Status = STATUS_POISON
+
+	next, events := playTurn(t, e, state, attack(player1, willOWisp), attack(player2, tackle))
+	if _, got := findEvent(events, EVENT_STATUS, player2); got || next.Player2.Active().Status != STATUS_POISON {
+		t.Fatal("a pokemon keeps the status it already has")
+	}
+}
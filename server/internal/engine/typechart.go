@@ -0,0 +1,98 @@
+package engine
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+// Same-type attack bonus applied when the move type matches one of the attacker's types
+const STAB_MULTIPLIER = 1.5
+
+// Labels reported to clients describing how effective a move was
+const (
+	EFFECTIVENESS_NONE     = "no_effect"
+	EFFECTIVENESS_NOT_VERY = "not_very_effective"
+	EFFECTIVENESS_NORMAL   = "normal"
+	EFFECTIVENESS_SUPER    = "super_effective"
+)
+
+//go:embed typechart.json
+var typeChartJSON []byte
+
+// DefaultTypeChart is the chart embedded in the binary, used when a BattleService has none configured
+var DefaultTypeChart = mustLoadTypeChart(typeChartJSON)
+
+// TypeChart maps an attacking type to the multiplier it gets against each defending type.
+// Pairs missing from the chart are neutral (x1).
+type TypeChart map[string]map[string]float64
+
+// LoadTypeChart parses a JSON type chart of the form {"fire": {"grass": 2, "water": 0.5}}
+func LoadTypeChart(data []byte) (TypeChart, error) {
+	var chart TypeChart
+	if err := json.Unmarshal(data, &chart); err != nil {
+		return nil, fmt.Errorf("failed to parse type chart: %w", err)
+	}
+	return chart, nil
+}
+
+func mustLoadTypeChart(data []byte) TypeChart {
+	chart, err := LoadTypeChart(data)
+	if err != nil {
+		panic(err)
+	}
+	return chart
+}
+
+// Effectiveness returns the stacked multiplier of an attack type against every defender type.
+// Empty defender types (e.g. a missing type2) are ignored.
+func (c TypeChart) Effectiveness(attackType string, defenderTypes ...string) float64 {
+	multiplier := 1.0
+	for _, defenderType := range defenderTypes {
+		if defenderType == "" {
+			continue
+		}
+		if m, ok := c[attackType][defenderType]; ok {
+			multiplier *= m
+		}
+	}
+	return multiplier
+}
+
+// STAB returns the same-type attack bonus for a move used by a pokemon of the given types
+func STAB(moveType string, attackerTypes ...string) float64 {
+	for _, attackerType := range attackerTypes {
+		if attackerType != "" && attackerType == moveType {
+			return STAB_MULTIPLIER
+		}
+	}
+	return 1.0
+}
+
+// EffectivenessLabel classifies a type multiplier for clients
+func EffectivenessLabel(multiplier float64) string {
+	switch {
+	case multiplier == 0:
+		return EFFECTIVENESS_NONE
+	case multiplier < 1:
+		return EFFECTIVENESS_NOT_VERY
+	case multiplier > 1:
+		return EFFECTIVENESS_SUPER
+	default:
+		return EFFECTIVENESS_NORMAL
+	}
+}
+
+// effectivenessMessage is the text appended to battle messages for non-neutral hits
+func effectivenessMessage(multiplier float64) string {
+	switch EffectivenessLabel(multiplier) {
+	case EFFECTIVENESS_NONE:
+		return " It had no effect..."
+	case EFFECTIVENESS_NOT_VERY:
+		return " It's not very effective..."
+	case EFFECTIVENESS_SUPER:
+		return " It's super effective!"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,80 @@
+package engine
+
+import "testing"
+
+func TestEffectiveness(t *testing.T) {
+	tests := []struct {
+		name      string
+		attack    string
+		defenders []string
+		want      float64
+		label     string
+	}{
+		{"neutral", "normal", []string{"fire"}, 1, EFFECTIVENESS_NORMAL},
+		{"super effective", "fire", []string{"grass"}, 2, EFFECTIVENESS_SUPER},
+		{"not very effective", "fire", []string{"water"}, 0.5, EFFECTIVENESS_NOT_VERY},
+		{"immune", "normal", []string{"ghost"}, 0, EFFECTIVENESS_NONE},
+		{"missing type2 is ignored", "fire", []string{"grass", ""}, 2, EFFECTIVENESS_SUPER},
+		{"both types weak", "fire", []string{"grass", "steel"}, 4, EFFECTIVENESS_SUPER},
+		{"weak and resistant cancel out", "fire", []string{"grass", "water"}, 1, EFFECTIVENESS_NORMAL},
+		{"both types resist", "fire", []string{"water", "rock"}, 0.25, EFFECTIVENESS_NOT_VERY},
+		{"immunity wins over a weakness", "electric", []string{"water", "ground"}, 0, EFFECTIVENESS_NONE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DefaultTypeChart.Effectiveness(tt.attack, tt.defenders...)
+			if got != tt.want {
+				t.Fatalf("%s against %v: expected x%v, got x%v", tt.attack, tt.defenders, tt.want, got)
+			}
+			if label := EffectivenessLabel(got); label != tt.label {
+				t.Fatalf("expected %s, got %s", tt.label, label)
+			}
+		})
+	}
+}
+
+func TestSTAB(t *testing.T) {
+	tests := []struct {
+		name     string
+		move     string
+		attacker []string
+		want     float64
+	}{
+		{"matches type1", "fire", []string{"fire", ""}, STAB_MULTIPLIER},
+		{"matches type2", "flying", []string{"fire", "flying"}, STAB_MULTIPLIER},
+		{"no match", "water", []string{"fire", "flying"}, 1},
+		{"empty move type", "", []string{"fire", ""}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := STAB(tt.move, tt.attacker...); got != tt.want {
+				t.Fatalf("expected x%v, got x%v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTypesInDamage(t *testing.T) {
+	e := testEngine(rolls(99))
+	e.Catalog.Species[phantom.ID] = phantom
+	blaze := &Pokemon{SpeciesID: BLAZE, HP: 100}
+	swift := &Pokemon{SpeciesID: SWIFT, HP: 100}
+	sluggish := &Pokemon{SpeciesID: SLUGGISH, HP: 100}
+	ghost := &Pokemon{SpeciesID: phantom.ID, HP: 100}
+	ember := Move{ID: 40, Name: "Ember", Type: "fire", Power: 40, Accuracy: 100, PP: 25}
+
+	// Blaze and Swift have the same stats, only Blaze is a fire type
+	plain, effectiveness := e.damage(swift, sluggish, ember, 1)
+	if effectiveness != 1 {
+		t.Fatalf("fire against normal should be neutral, got x%v", effectiveness)
+	}
+	if stab, _ := e.damage(blaze, sluggish, ember, 1); stab != int32(float64(plain)*STAB_MULTIPLIER) {
+		t.Fatalf("a fire move from a fire type should deal x%v, got %d against %d", STAB_MULTIPLIER, stab, plain)
+	}
+	if resisted, _ := e.damage(swift, blaze, ember, 1); resisted != int32(float64(plain)*0.5) {
+		t.Fatalf("fire against fire should deal half, got %d against %d", resisted, plain)
+	}
+	if damage, effectiveness := e.damage(swift, ghost, tackle, 1); damage != 0 || effectiveness != 0 {
+		t.Fatalf("normal moves should not touch ghosts, got %d damage", damage)
+	}
+}
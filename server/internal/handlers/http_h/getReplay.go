@@ -0,0 +1,125 @@
+package http_h
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+type ReplayEventInfo struct {
+	Sequence      int32  `json:"sequence"`
+	Type          string `json:"type"` // team, action, switch, attack, move, miss, recoil, drain, faint, status, status_damage, cant_move, cure, stat, end
+	PlayerID      string `json:"player_id,omitempty"`
+	Position      *int32 `json:"position,omitempty"`
+	SpeciesID     *int32 `json:"species_id,omitempty"`
+	ActionType    string `json:"action_type,omitempty"`
+	MoveID        *int32 `json:"move_id,omitempty"`
+	Damage        *int32 `json:"damage,omitempty"`
+	RemainingHP   *int32 `json:"remaining_hp,omitempty"`
+	Effectiveness string `json:"effectiveness,omitempty"`
+	CriticalHit   bool   `json:"critical_hit,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Stat          string `json:"stat,omitempty"`
+	StatChange    *int32 `json:"stat_change,omitempty"`
+	EndReason     string `json:"end_reason,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// ReplayTurnInfo holds the events of a turn, turn 0 holds the starting teams
+type ReplayTurnInfo struct {
+	Turn   int32             `json:"turn"`
+	Events []ReplayEventInfo `json:"events"`
+}
+
+type ReplayResponse struct {
+	BattleID  string           `json:"battle_id"`
+	Status    string           `json:"status"`
+	Ranked    bool             `json:"ranked"`
+	StartedAt *time.Time       `json:"started_at,omitempty"`
+	EndedAt   *time.Time       `json:"ended_at,omitempty"`
+	Turns     []ReplayTurnInfo `json:"turns"`
+}
+
+// GetReplay returns every recorded event of a battle, grouped by turn
+func GetReplay(battleService *battle_s.BattleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var battleID pgtype.UUID
+		if err := battleID.Scan(chi.URLParam(r, "battleID")); err != nil {
+			writeError(w, utils.BadRequest, map[string]string{"error": "Invalid UUID format"})
+			return
+		}
+
+		replay, err := battleService.GetReplay(r.Context(), battleID)
+		if err != nil {
+			log.Error().Err(err).Str("battle_id", battleID.String()).Msg("Failed to get replay")
+			if errors.Is(err, battle_s.ErrBattleNotFound) {
+				writeError(w, utils.ResourceNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			writeError(w, utils.InternalServerError, map[string]string{"error": "Could not load the replay"})
+			return
+		}
+
+		response := ReplayResponse{
+			BattleID:  replay.Battle.ID.String(),
+			Status:    replay.Battle.Status.String,
+			Ranked:    replay.Battle.Ranked,
+			StartedAt: optionalTime(replay.Battle.StartedAt),
+			EndedAt:   optionalTime(replay.Battle.EndedAt),
+			Turns:     []ReplayTurnInfo{},
+		}
+		for _, event := range replay.Events {
+			if len(response.Turns) == 0 || response.Turns[len(response.Turns)-1].Turn != event.Turn {
+				response.Turns = append(response.Turns, ReplayTurnInfo{Turn: event.Turn, Events: []ReplayEventInfo{}})
+			}
+			turn := &response.Turns[len(response.Turns)-1]
+			turn.Events = append(turn.Events, ReplayEventInfo{
+				Sequence:      event.Sequence,
+				Type:          event.EventType,
+				PlayerID:      optionalUUID(event.PlayerID),
+				Position:      optionalInt(event.Position),
+				SpeciesID:     optionalInt(event.SpeciesID),
+				ActionType:    event.ActionType.String,
+				MoveID:        optionalInt(event.MoveID),
+				Damage:        optionalInt(event.Damage),
+				RemainingHP:   optionalInt(event.RemainingHp),
+				Effectiveness: event.Effectiveness.String,
+				CriticalHit:   event.CriticalHit,
+				Status:        event.Status.String,
+				Stat:          event.Stat.String,
+				StatChange:    optionalInt(event.StatChange),
+				EndReason:     event.EndReason.String,
+				Message:       event.Message,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+func optionalInt(value pgtype.Int4) *int32 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int32
+}
+
+func optionalUUID(value pgtype.UUID) string {
+	if !value.Valid {
+		return ""
+	}
+	return value.String()
+}
+
+func optionalTime(value pgtype.Timestamp) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
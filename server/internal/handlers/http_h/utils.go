@@ -1 +1,34 @@
 package http_h
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrorResponse has the same shape as the errors sent over the websocket
+type ErrorResponse struct {
+	Message string            `json:"msg"`
+	Code    int               `json:"code"`
+	Details map[string]string `json:"details"`
+}
+
+// writeJSON sends payload as the JSON body of the response
+func writeJSON(w http.ResponseWriter, statusCode int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Error().Err(err).Msg("Failed to write response")
+	}
+}
+
+// writeError sends one of the common response messages with the given details
+func writeError(w http.ResponseWriter, msg *utils.DefaultMsg, details map[string]string) {
+	writeJSON(w, msg.StatusCode, ErrorResponse{
+		Message: msg.Message,
+		Code:    msg.StatusCode,
+		Details: details,
+	})
+}
@@ -0,0 +1,243 @@
+package ws_h
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// ActiveBattle links a battle being played on this server to the connections of its players.
+// Player1 and Player2 match battles.player1_id and battles.player2_id.
+type ActiveBattle struct {
+	BattleID pgtype.UUID
+	Player1  *Connection
+	Player2  *Connection
+	Clock    *TurnClock // nil when turns have no time limit
+
+	spectators map[PlayerID]bool // Guarded by the registry, shared by every copy of the battle
+}
+
+// Has reports whether the player takes part in the battle
+func (b *ActiveBattle) Has(playerID PlayerID) bool {
+	return b.Player1.PlayerID == playerID || b.Player2.PlayerID == playerID
+}
+
+// Player returns the connection of the given player of the battle
+func (b *ActiveBattle) Player(playerID PlayerID) *Connection {
+	if b.Player1.PlayerID == playerID {
+		return b.Player1
+	}
+	return b.Player2
+}
+
+// Opponent returns the connection of the other player of the battle
+func (b *ActiveBattle) Opponent(playerID PlayerID) *Connection {
+	if b.Player1.PlayerID == playerID {
+		return b.Player2
+	}
+	return b.Player1
+}
+
+// BattleRegistry keeps track of the battles being played on this server
+type BattleRegistry struct {
+	mu      sync.RWMutex
+	battles map[pgtype.UUID]*ActiveBattle
+}
+
+func NewBattleRegistry() *BattleRegistry {
+	return &BattleRegistry{
+		battles: make(map[pgtype.UUID]*ActiveBattle),
+	}
+}
+
+// Add registers a battle that just started
+func (r *BattleRegistry) Add(battle *ActiveBattle) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if battle.spectators == nil {
+		battle.spectators = make(map[PlayerID]bool)
+	}
+	r.battles[battle.BattleID] = battle
+}
+
+// List returns every battle being played
+func (r *BattleRegistry) List() []*ActiveBattle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	battles := make([]*ActiveBattle, 0, len(r.battles))
+	for _, battle := range r.battles {
+		battles = append(battles, battle)
+	}
+	return battles
+}
+
+// Get returns the battle with the given ID, if it is being played
+func (r *BattleRegistry) Get(battleID pgtype.UUID) (*ActiveBattle, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	battle, exists := r.battles[battleID]
+	return battle, exists
+}
+
+// Reattach points the battles of the player to their new connection
+func (r *BattleRegistry) Reattach(conn *Connection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for battleID, battle := range r.battles {
+		if !battle.Has(conn.PlayerID) {
+			continue
+		}
+		// Replace the entry instead of mutating it, handlers may be holding it
+		updated := *battle
+		if updated.Player1.PlayerID == conn.PlayerID {
+			updated.Player1 = conn
+		} else {
+			updated.Player2 = conn
+		}
+		r.battles[battleID] = &updated
+	}
+}
+
+// ByPlayer returns every battle the player takes part in
+func (r *BattleRegistry) ByPlayer(playerID PlayerID) []*ActiveBattle {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	battles := []*ActiveBattle{}
+	for _, battle := range r.battles {
+		if battle.Has(playerID) {
+			battles = append(battles, battle)
+		}
+	}
+	return battles
+}
+
+// Remove forgets a battle once it is over
+func (r *BattleRegistry) Remove(battleID pgtype.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if battle, exists := r.battles[battleID]; exists {
+		battle.Clock.Stop()
+	}
+	delete(r.battles, battleID)
+}
+
+// Watch subscribes a spectator to the battle, failing if it is not being played
+// or the spectator plays in it
+func (r *BattleRegistry) Watch(battleID pgtype.UUID, playerID PlayerID) (*ActiveBattle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	battle, exists := r.battles[battleID]
+	if !exists {
+		return nil, fmt.Errorf("battle not found")
+	}
+	if battle.Has(playerID) {
+		return nil, fmt.Errorf("you can't spectate your own battle")
+	}
+	battle.spectators[playerID] = true
+	return battle, nil
+}
+
+// Unwatch unsubscribes a spectator from the battle, reporting if they were watching it
+func (r *BattleRegistry) Unwatch(battleID pgtype.UUID, playerID PlayerID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	battle, exists := r.battles[battleID]
+	if !exists || !battle.spectators[playerID] {
+		return false
+	}
+	delete(battle.spectators, playerID)
+	return true
+}
+
+// UnwatchAll unsubscribes a spectator from every battle they watch
+func (r *BattleRegistry) UnwatchAll(playerID PlayerID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, battle := range r.battles {
+		delete(battle.spectators, playerID)
+	}
+}
+
+// Spectators returns who is watching the battle, it still works once the battle was removed
+func (r *BattleRegistry) Spectators(battle *ActiveBattle) []PlayerID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spectators := make([]PlayerID, 0, len(battle.spectators))
+	for playerID := range battle.spectators {
+		spectators = append(spectators, playerID)
+	}
+	return spectators
+}
+
+// battleOf returns the battle the player of conn wants to act in,
+// failing if the battle is not being played or the player is not part of it
+func (h *Handler) battleOf(conn *Connection, battleID pgtype.UUID) (*ActiveBattle, error) {
+	battle, exists := h.Battles.Get(battleID)
+	if !exists {
+		return nil, fmt.Errorf("battle not found")
+	}
+	if !battle.Has(conn.PlayerID) {
+		return nil, fmt.Errorf("you are not part of this battle")
+	}
+	return battle, nil
+}
+
+// OpponentDisconnectedResponse tells a player that their opponent left or came back.
+// While the opponent is away the battle is forfeited in ForfeitIn seconds.
+type OpponentDisconnectedResponse struct {
+	BattleID    pgtype.UUID `json:"battle_id"`
+	PlayerID    PlayerID    `json:"player_id"`
+	Reconnected bool        `json:"reconnected"`
+	ForfeitIn   int         `json:"forfeit_in,omitempty"`
+}
+
+// notifyOpponents lets the opponents of the player know they disconnected or reconnected
+func (h *Handler) notifyOpponents(playerID PlayerID, reconnected bool) {
+	forfeitIn := 0
+	if !reconnected {
+		forfeitIn = int(h.ReconnectGracePeriod.Seconds())
+	}
+
+	for _, battle := range h.Battles.ByPlayer(playerID) {
+		opponentConn := battle.Opponent(playerID)
+		err := h.SendToPlayer(opponentConn.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.Disconnect, OpponentDisconnectedResponse{
+			BattleID:    battle.BattleID,
+			PlayerID:    playerID,
+			Reconnected: reconnected,
+			ForfeitIn:   forfeitIn,
+		}))
+		if err != nil {
+			log.Warn().Err(err).Str("opponent_id", opponentConn.PlayerID.String()).Msg("Failed to notify opponent")
+		}
+	}
+}
+
+// forfeitBattles ends every battle the player takes part in, giving the win to their
+// opponents and letting them know the battle is over
+func (h *Handler) forfeitBattles(playerID PlayerID, reason string) {
+	for _, battle := range h.Battles.ByPlayer(playerID) {
+		h.Battles.Remove(battle.BattleID)
+
+		battleState, err := h.BattleService.Forfeit(context.Background(), battle.BattleID, playerID, reason)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("battle_id", battle.BattleID.String()).
+				Str("player_id", playerID.String()).
+				Msg("Failed to forfeit battle")
+			continue
+		}
+
+		h.notifySpectators(battle, battleState)
+
+		opponentConn := battle.Opponent(playerID)
+		_, opponentResponse := battleStateResponses(battle.Player(playerID), opponentConn, battleState)
+		err = h.SendToPlayer(opponentConn.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.BattleEnded, opponentResponse))
+		if err != nil {
+			log.Warn().Err(err).Str("opponent_id", opponentConn.PlayerID.String()).Msg("Failed to notify opponent")
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package ws_h
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
+	"github.com/DanielRasho/PokeSocket/internal/services/matchmaking_s"
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// Bots read their messages while they act, so they get more room than a socket
+const BOT_SEND_BUFFER_SIZE = 16
+
+// Size of the random team a bot battles with
+const BOT_TEAM_SIZE = 3
+
+type PlayBotRequest struct {
+	Level string `json:"level" validate:"omitempty,oneof=random greedy lookahead"`
+}
+
+// handlePlayBot starts a battle against a bot of the requested level, greedy by default
+func (h *Handler) handlePlayBot(conn *Connection, msg Message) {
+	var payload PlayBotRequest
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields,
+				map[string]string{"error": "Invalid payload"})
+			return
+		}
+	}
+	if details, err := utils.ValidateStruct(&h.Validator, payload); err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields, details)
+		return
+	}
+	if payload.Level == "" {
+		payload.Level = engine.BOT_LEVEL_GREEDY
+	}
+
+	if err := h.MatchmakingService.CheckNotWaiting(conn.PlayerID); err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+
+	// CREATE BOT USER AND TEAM IN DATABASE
+	team, err := h.UserService.RandomTeam(ctx, BOT_TEAM_SIZE)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": "Could not build a team for the bot"})
+		return
+	}
+
+	botID := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	botName := fmt.Sprintf("Bot (%s)", payload.Level)
+	err = h.UserService.CreateUserWithTeam(ctx, botID, botName, team)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": "Could not create the bot"})
+		return
+	}
+
+	// Bots have no socket, they read what the server sends them straight from the channel
+	bot := NewConnection(botID, botName, team, nil, context.Background())
+	bot.Send = make(chan Message, BOT_SEND_BUFFER_SIZE)
+	h.AddConnection(bot)
+	go h.runBot(bot, payload.Level)
+
+	log.Info().
+		Str("player_id", conn.PlayerID.String()).
+		Str("bot_id", botID.String()).
+		Str("level", payload.Level).
+		Msg("Bot battle requested")
+
+	h.startBattle(
+		matchmaking_s.QueuedPlayer{PlayerID: conn.PlayerID, Username: conn.Username},
+		matchmaking_s.QueuedPlayer{PlayerID: botID, Username: botName},
+		false,
+	)
+}
+
+// runBot plays the bot's side of its battle, choosing an action whenever a new
+// turn begins or its fainted pokemon has to be replaced, and removes the bot once
+// the battle is over.
+func (h *Handler) runBot(bot *Connection, level string) {
+	for message := range bot.Send {
+		switch message.Type {
+		case SERVER_MESSAGE_TYPE.MatchFound, SERVER_MESSAGE_TYPE.TurnResult:
+			var state struct {
+				BattleID    string `json:"battle_id"`
+				BattleEnded bool   `json:"battle_ended"`
+				YourInfo    struct {
+					MustSwitch bool `json:"must_switch"`
+				} `json:"your_info"`
+				OpponentInfo struct {
+					MustSwitch bool `json:"must_switch"`
+				} `json:"opponent_info"`
+			}
+			if err := json.Unmarshal(message.Payload, &state); err != nil || state.BattleEnded {
+				continue
+			}
+			// The turn goes on once the opponent replaced its fainted pokemon
+			if state.OpponentInfo.MustSwitch && !state.YourInfo.MustSwitch {
+				continue
+			}
+			h.botAct(bot, state.BattleID, level)
+
+		case SERVER_MESSAGE_TYPE.BattleEnded, SERVER_MESSAGE_TYPE.Error:
+			// The bot only lives for one battle, or gives up if it could not start
+			go h.RemoveConnection(bot.PlayerID)
+		}
+	}
+
+	log.Debug().Str("bot_id", bot.PlayerID.String()).Msg("Bot stopped")
+}
+
+// botAct chooses the bot's action for the current turn and submits it like a player would.
+// Bots have no socket to be told their action was rejected, so if the bot can't choose or
+// submit one it falls back to the default action, see battle_s.DefaultAction, and gives up
+// the battle when even that fails, instead of leaving its opponent waiting.
+func (h *Handler) botAct(bot *Connection, battleID string, level string) {
+	var battleUUID pgtype.UUID
+	if err := battleUUID.Scan(battleID); err != nil {
+		return
+	}
+	battle, exists := h.Battles.Get(battleUUID)
+	if !exists {
+		return
+	}
+	ctx := context.Background()
+
+	action, err := h.BattleService.BotAction(ctx, battleUUID, bot.PlayerID, level)
+	if err == nil {
+		err = h.submitBotAction(bot, battle, action)
+	}
+	if err == nil {
+		return
+	}
+	log.Warn().Err(err).Str("bot_id", bot.PlayerID.String()).Str("battle_id", battleID).Msg("Bot failed to act, using the default action")
+
+	action, err = h.BattleService.DefaultAction(ctx, battleUUID, bot.PlayerID)
+	if err == nil {
+		err = h.submitBotAction(bot, battle, action)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("bot_id", bot.PlayerID.String()).Str("battle_id", battleID).Msg("Bot has no action, forfeiting")
+		// Removing the bot forfeits its battle, and stops runBot, which is where this runs from
+		go h.RemoveConnection(bot.PlayerID)
+	}
+}
+
+// submitBotAction submits the action of the bot for the current turn
+func (h *Handler) submitBotAction(bot *Connection, battle *ActiveBattle, action battle_s.TurnAction) error {
+	battleState, err := h.BattleService.SubmitAction(context.Background(), battle_s.SubmitActionRequest{
+		BattleID: battle.BattleID,
+		PlayerID: bot.PlayerID,
+		Action:   action,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Bots need no acknowledgement, and this runs from runBot, the only reader of
+	// bot.Send, so a blocking send could never be received
+	if battleState.TurnResolved {
+		h.broadcastTurnResult(battle, battleState)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package ws_h
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DanielRasho/PokeSocket/internal/config"
+	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// TurnClockResponse tells the players how long they have left to choose their action
+type TurnClockResponse struct {
+	BattleID         string `json:"battle_id"`
+	Turn             int32  `json:"turn"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+}
+
+// TurnClock limits the time players have to choose their action each turn.
+// A nil clock is a battle without time limit.
+type TurnClock struct {
+	restart  chan int32
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newTurnClock starts the clock of a battle on its first turn,
+// or returns nil if turns have no time limit
+func (h *Handler) newTurnClock(battleID pgtype.UUID) *TurnClock {
+	if h.TurnTimeout <= 0 {
+		return nil
+	}
+	clock := &TurnClock{
+		restart: make(chan int32, 1),
+		done:    make(chan struct{}),
+	}
+	go h.runTurnClock(clock, battleID)
+	return clock
+}
+
+// Restart gives the players the whole time again for the given turn
+func (c *TurnClock) Restart(turn int32) {
+	if c == nil {
+		return
+	}
+	select {
+	case c.restart <- turn:
+	case <-c.done:
+	}
+}
+
+// Stop ends the clock once the battle is over
+func (c *TurnClock) Stop() {
+	if c == nil {
+		return
+	}
+	c.stopOnce.Do(func() { close(c.done) })
+}
+
+func (h *Handler) runTurnClock(clock *TurnClock, battleID pgtype.UUID) {
+	turn := int32(1)
+	deadline := time.Now().Add(h.TurnTimeout)
+	expiry := time.NewTimer(h.TurnTimeout)
+	defer expiry.Stop()
+
+	var ticks <-chan time.Time
+	var ticker *time.Ticker
+	if h.TurnClockTick > 0 {
+		ticker = time.NewTicker(h.TurnClockTick)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-clock.done:
+			return
+
+		case turn = <-clock.restart:
+			deadline = time.Now().Add(h.TurnTimeout)
+			expiry.Reset(h.TurnTimeout)
+			if ticker != nil {
+				ticker.Reset(h.TurnClockTick)
+			}
+
+		case <-ticks:
+			remaining := time.Until(deadline).Round(time.Second)
+			if remaining > 0 {
+				h.sendTurnClock(battleID, turn, remaining)
+			}
+
+		case <-expiry.C:
+			// Resolving the turn restarts the clock, which can't happen from here
+			go h.expireTurn(battleID, turn)
+
+			// Until then the clock keeps running, so the turn expires again if
+			// expireTurn could not resolve it and the battle never loses its time limit
+			deadline = time.Now().Add(h.TurnTimeout)
+			expiry.Reset(h.TurnTimeout)
+		}
+	}
+}
+
+// sendTurnClock tells both players of the battle the time left for the turn
+func (h *Handler) sendTurnClock(battleID pgtype.UUID, turn int32, remaining time.Duration) {
+	battle, exists := h.Battles.Get(battleID)
+	if !exists {
+		return
+	}
+	msg := NewMessage(SERVER_MESSAGE_TYPE.TurnClock, TurnClockResponse{
+		BattleID:         battleID.String(),
+		Turn:             turn,
+		RemainingSeconds: int(remaining.Seconds()),
+	})
+	for _, player := range []*Connection{battle.Player1, battle.Player2} {
+		if err := h.SendToPlayer(player.PlayerID, msg); err != nil {
+			log.Debug().Err(err).Str("player_id", player.PlayerID.String()).Msg("Failed to send turn clock")
+		}
+	}
+}
+
+// expireTurn acts for the players that did not choose an action before the clock ran out.
+// Failing to do so is only logged, runTurnClock calls it again once the clock runs out anew.
+// With TURN_TIMEOUT_FORFEIT a single idle player loses the battle, if both are idle
+// nobody deserves the win and default actions are used instead. Fainted pokemon that
+// were not replaced in time are always replaced by the first one able to battle.
+func (h *Handler) expireTurn(battleID pgtype.UUID, turn int32) {
+	battle, exists := h.Battles.Get(battleID)
+	if !exists {
+		return
+	}
+	ctx := context.Background()
+
+	switching, err := h.BattleService.SwitchingPlayers(ctx, battleID, turn)
+	if err != nil {
+		log.Error().Err(err).Str("battle_id", battleID.String()).Msg("Failed to get switching players")
+		return
+	}
+	if len(switching) > 0 {
+		log.Info().
+			Str("battle_id", battleID.String()).
+			Int32("turn", turn).
+			Int("switching_players", len(switching)).
+			Msg("Turn clock ran out before fainted pokemon were replaced")
+
+		h.submitDefaultActions(battle, switching)
+		return
+	}
+
+	idle, err := h.BattleService.IdlePlayers(ctx, battleID, turn)
+	if err != nil {
+		log.Error().Err(err).Str("battle_id", battleID.String()).Msg("Failed to get idle players")
+		return
+	}
+	if len(idle) == 0 {
+		return
+	}
+
+	log.Info().
+		Str("battle_id", battleID.String()).
+		Int32("turn", turn).
+		Int("idle_players", len(idle)).
+		Msg("Turn clock ran out")
+
+	if h.TurnTimeoutAction == config.TURN_TIMEOUT_FORFEIT && len(idle) == 1 {
+		h.forfeitOnTimeout(battle, idle[0])
+		return
+	}
+
+	h.submitDefaultActions(battle, idle)
+}
+
+// submitDefaultActions submits the default action of each player, see battle_s.DefaultAction,
+// forfeiting the battle for a player that has none
+func (h *Handler) submitDefaultActions(battle *ActiveBattle, players []PlayerID) {
+	ctx := context.Background()
+
+	for _, playerID := range players {
+		action, err := h.BattleService.DefaultAction(ctx, battle.BattleID, playerID)
+		if err != nil {
+			log.Error().Err(err).Str("player_id", playerID.String()).Msg("No default action, forfeiting")
+			h.forfeitOnTimeout(battle, playerID)
+			return
+		}
+
+		battleState, err := h.BattleService.SubmitAction(ctx, battle_s.SubmitActionRequest{
+			BattleID: battle.BattleID,
+			PlayerID: playerID,
+			Action:   action,
+		})
+		if err != nil {
+			// The player may have chosen just in time
+			log.Warn().Err(err).Str("player_id", playerID.String()).Msg("Failed to submit default action")
+			continue
+		}
+		if battleState.TurnResolved {
+			h.broadcastTurnResult(battle, battleState)
+		}
+	}
+}
+
+// forfeitOnTimeout ends the battle giving the win to the opponent of the idle player
+func (h *Handler) forfeitOnTimeout(battle *ActiveBattle, playerID PlayerID) {
+	h.Battles.Remove(battle.BattleID)
+
+	battleState, err := h.BattleService.Forfeit(context.Background(), battle.BattleID, playerID, battle_s.END_REASON_TIMEOUT)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("battle_id", battle.BattleID.String()).
+			Str("player_id", playerID.String()).
+			Msg("Failed to forfeit battle")
+		return
+	}
+
+	h.broadcastBattleState(battle, SERVER_MESSAGE_TYPE.BattleEnded, battleState)
+	h.notifySpectators(battle, battleState)
+}
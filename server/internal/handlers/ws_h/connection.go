@@ -12,13 +12,14 @@ import (
 const SESSION_WRITE_BUFFER_SIZE = 4
 
 type Connection struct {
-	PlayerID PlayerID
-	Username string
-	Pokemons []int
-	Conn     *websocket.Conn
-	Send     chan Message // Buffered channel for async sends
-	Ctx      context.Context
-	Cancel   context.CancelFunc
+	PlayerID    PlayerID
+	Username    string
+	Pokemons    []int
+	ResumeToken string // Lets the player reattach to this session after a disconnection
+	Conn        *websocket.Conn
+	Send        chan Message // Buffered channel for async sends
+	Ctx         context.Context
+	Cancel      context.CancelFunc
 }
 
 // Close gracefully closes the connection
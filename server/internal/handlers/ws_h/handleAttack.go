@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/DanielRasho/PokeSocket/internal/engine"
 	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
 	"github.com/DanielRasho/PokeSocket/utils"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -15,16 +16,42 @@ type AttackRequestPayload struct {
 	MoveID   int    `json:"move_id" validate:"required"`
 }
 
+// ActionQueuedResponse acknowledges an action while the opponent is still choosing
+type ActionQueuedResponse struct {
+	BattleID string `json:"battle_id"`
+	Turn     int32  `json:"turn"`
+	Message  string `json:"message"`
+}
+
+type BattleEventInfo struct {
+	Type          string `json:"type"` // switch, attack, move, miss, recoil, drain, faint, status, status_damage, cant_move, cure, stat
+	PlayerID      string `json:"player_id"`
+	Position      int32  `json:"position"`
+	MoveID        int32  `json:"move_id,omitempty"`
+	MoveName      string `json:"move_name,omitempty"`
+	Damage        int32  `json:"damage,omitempty"`
+	RemainingHP   int32  `json:"remaining_hp"`
+	Effectiveness string `json:"effectiveness,omitempty"` // no_effect, not_very_effective, normal, super_effective
+	CriticalHit   bool   `json:"critical_hit,omitempty"`
+	Status        string `json:"status,omitempty"` // burn, paralysis, poison, sleep, freeze
+	Stat          string `json:"stat,omitempty"`   // attack, defense, speed
+	StatChange    int32  `json:"stat_change,omitempty"`
+	Message       string `json:"message"`
+}
+
 type BattleStateResponse struct {
-	BattleID     string           `json:"battle_id"`
-	Message      string           `json:"message"`
-	YourInfo     PlayerBattleInfo `json:"your_info"`
-	OpponentInfo PlayerBattleInfo `json:"opponent_info"`
-	BattleEnded  bool             `json:"battle_ended,omitempty"`
-	Winner       string           `json:"winner,omitempty"` // player_id of winner
+	BattleID     string            `json:"battle_id"`
+	Turn         int32             `json:"turn"`
+	Message      string            `json:"message"`
+	Events       []BattleEventInfo `json:"events"` // in the order they happened
+	YourInfo     PlayerBattleInfo  `json:"your_info"`
+	OpponentInfo PlayerBattleInfo  `json:"opponent_info"`
+	BattleEnded  bool              `json:"battle_ended,omitempty"`
+	Winner       string            `json:"winner,omitempty"`     // player_id of winner
+	EndReason    string            `json:"end_reason,omitempty"` // see battle_s.END_REASON_*
 }
 
-// handleAttack processes an attack action in a battle
+// handleAttack submits an attack as the player's action for the current turn
 func (h *Handler) handleAttack(conn *Connection, msg Message) {
 	var payload AttackRequestPayload
 	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
@@ -43,49 +70,116 @@ func (h *Handler) handleAttack(conn *Connection, msg Message) {
 		return
 	}
 
-	// Get opponent ID from connections map
-	// TODO: Better way to get opponent ID from battle
-	var opponentID pgtype.UUID
-	for playerID := range h.Connections {
-		if playerID != conn.PlayerID {
-			opponentID = playerID
-			break
-		}
-	}
-
-	// Process the attack
-	attackReq := battle_s.AttackRequest{
-		BattleID:   battleUUID,
-		AttackerID: conn.PlayerID,
-		DefenderID: opponentID,
-		MoveID:     payload.MoveID,
+	battle, err := h.battleOf(conn, battleUUID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
 	}
 
-	battleState, err := h.BattleService.AttackPokemon(ctx, attackReq)
+	// Submit the attack
+	battleState, err := h.BattleService.SubmitAction(ctx, battle_s.SubmitActionRequest{
+		BattleID: battleUUID,
+		PlayerID: conn.PlayerID,
+		Action: battle_s.TurnAction{
+			Type:   engine.ACTION_ATTACK,
+			MoveID: int32(payload.MoveID),
+		},
+	})
 	if err != nil {
 		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
 			map[string]string{"error": err.Error()})
 		return
 	}
 
-	// Convert teams to PokemonInfo
-	player1Team := make([]PokemonInfo, len(battleState.Player1Team))
-	for i, poke := range battleState.Player1Team {
-		player1Team[i] = PokemonInfo{
-			SpeciesID: int(poke.PokemonSpeciesID.Int32),
-			Position:  poke.Position,
-			CurrentHP: poke.CurrentHp,
-			IsFainted: poke.IsFainted.Bool,
-		}
+	h.sendTurnResult(conn, battle, SERVER_MESSAGE_TYPE.Attack, battleState)
+
+	log.Info().
+		Str("player_id", conn.PlayerID.String()).
+		Str("battle_id", payload.BattleID).
+		Int("move_id", payload.MoveID).
+		Bool("turn_resolved", battleState.TurnResolved).
+		Bool("battle_ended", battleState.BattleEnded).
+		Msg("Attack submitted")
+}
+
+// sendTurnResult acknowledges a submitted action with ackType, or, once the turn
+// is resolved, sends the resulting battle state to both players.
+func (h *Handler) sendTurnResult(conn *Connection, battle *ActiveBattle, ackType int, battleState *battle_s.BattleStateResult) {
+	if !battleState.TurnResolved {
+		conn.Send <- NewMessage(ackType, ActionQueuedResponse{
+			BattleID: battleState.BattleID.String(),
+			Turn:     battleState.Turn,
+			Message:  battleState.Message,
+		})
+		return
+	}
+
+	h.broadcastTurnResult(battle, battleState)
+}
+
+// broadcastTurnResult sends a resolved turn to both players, and lets them know
+// if the battle is over. Otherwise the clock starts again for the next turn, or
+// for the same one after a fainted pokemon was replaced.
+func (h *Handler) broadcastTurnResult(battle *ActiveBattle, battleState *battle_s.BattleStateResult) {
+	h.broadcastBattleState(battle, SERVER_MESSAGE_TYPE.TurnResult, battleState)
+	h.notifySpectators(battle, battleState)
+
+	if battleState.ForcedSwitch {
+		battle.Clock.Restart(battleState.Turn)
+		return
+	}
+	if !battleState.BattleEnded {
+		battle.Clock.Restart(battleState.Turn + 1)
+		return
+	}
+
+	h.Battles.Remove(battleState.BattleID)
+	h.broadcastBattleState(battle, SERVER_MESSAGE_TYPE.BattleEnded, battleState)
+
+	log.Info().
+		Str("battle_id", battleState.BattleID.String()).
+		Str("winner_id", battleState.WinnerID.String()).
+		Str("reason", battleState.EndReason).
+		Msg("Battle completed")
+}
+
+// broadcastBattleState sends the battle state to both players of the battle as msgType
+func (h *Handler) broadcastBattleState(battle *ActiveBattle, msgType int, battleState *battle_s.BattleStateResult) {
+	player1Response, player2Response := battleStateResponses(battle.Player1, battle.Player2, battleState)
+
+	err := h.SendToPlayer(battle.Player1.PlayerID, NewMessage(msgType, player1Response))
+	if err != nil {
+		log.Warn().Err(err).Str("player_id", battle.Player1.PlayerID.String()).Msg("Failed to send battle state")
+	}
+	err = h.SendToPlayer(battle.Player2.PlayerID, NewMessage(msgType, player2Response))
+	if err != nil {
+		log.Warn().Err(err).Str("player_id", battle.Player2.PlayerID.String()).Msg("Failed to send battle state")
 	}
+}
+
+// battleStateResponses builds the battle state as seen by the player of conn and by its opponent
+func battleStateResponses(conn, opponentConn *Connection, battleState *battle_s.BattleStateResult) (BattleStateResponse, BattleStateResponse) {
+	// Convert teams to PokemonInfo
+	player1Team := buildTeamInfo(battleState.Player1Team)
+	player2Team := buildTeamInfo(battleState.Player2Team)
 
-	player2Team := make([]PokemonInfo, len(battleState.Player2Team))
-	for i, poke := range battleState.Player2Team {
-		player2Team[i] = PokemonInfo{
-			SpeciesID: int(poke.PokemonSpeciesID.Int32),
-			Position:  poke.Position,
-			CurrentHP: poke.CurrentHp,
-			IsFainted: poke.IsFainted.Bool,
+	events := make([]BattleEventInfo, len(battleState.Events))
+	for i, event := range battleState.Events {
+		events[i] = BattleEventInfo{
+			Type:          event.Type,
+			PlayerID:      event.PlayerID.String(),
+			Position:      event.Position,
+			MoveID:        event.MoveID,
+			MoveName:      event.MoveName,
+			Damage:        event.Damage,
+			RemainingHP:   event.RemainingHP,
+			Effectiveness: event.Effectiveness,
+			CriticalHit:   event.CriticalHit,
+			Status:        event.Status,
+			Stat:          event.Stat,
+			StatChange:    event.StatChange,
+			Message:       event.Message,
 		}
 	}
 
@@ -93,6 +187,7 @@ func (h *Handler) handleAttack(conn *Connection, msg Message) {
 	var yourTeam, opponentTeam []PokemonInfo
 	var yourID, opponentPlayerID pgtype.UUID
 	var yourActivePos, opponentActivePos int32
+	var yourSwitching, opponentSwitching bool
 
 	if conn.PlayerID == battleState.Player1ID {
 		yourTeam = player1Team
@@ -101,6 +196,8 @@ func (h *Handler) handleAttack(conn *Connection, msg Message) {
 		opponentPlayerID = battleState.Player2ID
 		yourActivePos = battleState.Player1ActivePos
 		opponentActivePos = battleState.Player2ActivePos
+		yourSwitching = battleState.Player1Switching
+		opponentSwitching = battleState.Player2Switching
 	} else {
 		yourTeam = player2Team
 		opponentTeam = player1Team
@@ -108,99 +205,65 @@ func (h *Handler) handleAttack(conn *Connection, msg Message) {
 		opponentPlayerID = battleState.Player1ID
 		yourActivePos = battleState.Player2ActivePos
 		opponentActivePos = battleState.Player1ActivePos
+		yourSwitching = battleState.Player2Switching
+		opponentSwitching = battleState.Player1Switching
 	}
 
-	// Get opponent connection for username
-	h.mu.RLock()
-	opponentConn, opponentExists := h.Connections[opponentPlayerID]
-	h.mu.RUnlock()
-
-	if !opponentExists {
-		log.Error().Str("opponent_id", opponentPlayerID.String()).Msg("Opponent not found")
-		return
-	}
-
-	// Create response for attacker
-	attackerResponse := BattleStateResponse{
+	// Create response for the player that completed the turn
+	yourResponse := BattleStateResponse{
 		BattleID: battleState.BattleID.String(),
+		Turn:     battleState.Turn,
 		Message:  battleState.Message,
+		Events:   events,
 		YourInfo: PlayerBattleInfo{
 			PlayerID:      yourID.String(),
 			Username:      conn.Username,
 			Team:          yourTeam,
 			ActivePokemon: yourActivePos,
+			MustSwitch:    yourSwitching,
 		},
 		OpponentInfo: PlayerBattleInfo{
 			PlayerID:      opponentPlayerID.String(),
 			Username:      opponentConn.Username,
 			Team:          opponentTeam,
 			ActivePokemon: opponentActivePos,
+			MustSwitch:    opponentSwitching,
 		},
 		BattleEnded: battleState.BattleEnded,
+		EndReason:   battleState.EndReason,
 	}
 
 	if battleState.BattleEnded {
-		attackerResponse.Winner = battleState.WinnerID.String()
+		yourResponse.Winner = battleState.WinnerID.String()
 	}
 
-	// Create response for defender (swap your/opponent)
-	defenderResponse := BattleStateResponse{
+	// Create response for opponent (swap your/opponent)
+	opponentResponse := BattleStateResponse{
 		BattleID: battleState.BattleID.String(),
+		Turn:     battleState.Turn,
 		Message:  battleState.Message,
+		Events:   events,
 		YourInfo: PlayerBattleInfo{
 			PlayerID:      opponentPlayerID.String(),
 			Username:      opponentConn.Username,
 			Team:          opponentTeam,
 			ActivePokemon: opponentActivePos,
+			MustSwitch:    opponentSwitching,
 		},
 		OpponentInfo: PlayerBattleInfo{
 			PlayerID:      yourID.String(),
 			Username:      conn.Username,
 			Team:          yourTeam,
 			ActivePokemon: yourActivePos,
+			MustSwitch:    yourSwitching,
 		},
 		BattleEnded: battleState.BattleEnded,
+		EndReason:   battleState.EndReason,
 	}
 
 	if battleState.BattleEnded {
-		defenderResponse.Winner = battleState.WinnerID.String()
+		opponentResponse.Winner = battleState.WinnerID.String()
 	}
 
-	// Send to both players
-	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Attack, attackerResponse)
-
-	err = h.SendToPlayer(opponentPlayerID, NewMessage(SERVER_MESSAGE_TYPE.Attack, defenderResponse))
-	if err != nil {
-		log.Warn().Err(err).Str("opponent_id", opponentPlayerID.String()).Msg("Failed to notify opponent")
-	}
-
-	// If battle ended, delete it
-	if battleState.BattleEnded {
-
-		// Send to both players the battle ended
-		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.BattleEnded, attackerResponse)
-		err = h.SendToPlayer(opponentPlayerID, NewMessage(SERVER_MESSAGE_TYPE.Attack, defenderResponse))
-		if err != nil {
-			log.Warn().Err(err).Str("opponent_id", opponentPlayerID.String()).Msg("Failed to notify opponent")
-		}
-
-		if deleteErr := h.BattleService.DeleteBattle(ctx, battleUUID); deleteErr != nil {
-			log.Error().
-				Err(deleteErr).
-				Str("battle_id", payload.BattleID).
-				Msg("Failed to delete battle after completion")
-		} else {
-			log.Info().
-				Str("battle_id", payload.BattleID).
-				Str("winner_id", battleState.WinnerID.String()).
-				Msg("Battle completed and deleted")
-		}
-	}
-
-	log.Info().
-		Str("player_id", conn.PlayerID.String()).
-		Str("battle_id", payload.BattleID).
-		Int("move_id", payload.MoveID).
-		Bool("battle_ended", battleState.BattleEnded).
-		Msg("Attack processed and state sent to both players")
+	return yourResponse, opponentResponse
 }
@@ -19,8 +19,9 @@ type ClientConnectRequest struct {
 }
 
 type ClientConnectResponse struct {
-	Username string      `json:"username"`
-	Id       pgtype.UUID `json:"id"`
+	Username    string      `json:"username"`
+	Id          pgtype.UUID `json:"id"`
+	ResumeToken string      `json:"resume_token"` // send it with a Reconnect message after a disconnection
 }
 
 func (h *Handler) handleConnect(msg Message, ctx context.Context, conn *websocket.Conn) (*Connection, error) {
@@ -48,16 +49,24 @@ func (h *Handler) handleConnect(msg Message, ctx context.Context, conn *websocke
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	resumeToken, err := newResumeToken()
+	if err != nil {
+		return nil, err
+	}
+
 	// SEND SESSION DATA TO CLIENT.
 	err = wsjson.Write(ctx, conn, NewMessage(
 		SERVER_MESSAGE_TYPE.AcceptConnection,
 		ClientConnectResponse{
-			Username: payload.Username,
-			Id:       userId,
+			Username:    payload.Username,
+			Id:          userId,
+			ResumeToken: resumeToken,
 		}))
 	if err != nil {
 		return nil, nil
 	}
 
-	return NewConnection(userId, payload.Username, payload.Pokemons, conn, ctx), nil
+	connection := NewConnection(userId, payload.Username, payload.Pokemons, conn, ctx)
+	connection.ResumeToken = resumeToken
+	return connection, nil
 }
@@ -2,15 +2,31 @@ package ws_h
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/services/matchmaking_s"
+	"github.com/DanielRasho/PokeSocket/utils"
 	"github.com/rs/zerolog/log"
 )
 
+type MoveInfo struct {
+	MoveID int32  `json:"move_id"`
+	Name   string `json:"name"`
+	PP     int32  `json:"pp"`
+	MaxPP  int32  `json:"max_pp"`
+}
+
 type PokemonInfo struct {
-	SpeciesID int   `json:"species_id"`
-	Position  int32 `json:"position"`
-	CurrentHP int32 `json:"current_hp"`
-	IsFainted bool  `json:"is_fainted"`
+	SpeciesID int              `json:"species_id"`
+	Position  int32            `json:"position"`
+	CurrentHP int32            `json:"current_hp"`
+	IsFainted bool             `json:"is_fainted"`
+	Status    string           `json:"status"`          // burn, paralysis, poison, sleep, freeze or empty
+	Stages    map[string]int32 `json:"stages"`          // stage of attack, defense and speed, -6 to +6
+	Moves     []MoveInfo       `json:"moves,omitempty"` // moves with no PP left should be disabled
 }
 
 type PlayerBattleInfo struct {
@@ -18,119 +34,283 @@ type PlayerBattleInfo struct {
 	Username      string        `json:"username"`
 	Team          []PokemonInfo `json:"team"`
 	ActivePokemon int32         `json:"active_pokemon"`
+	MustSwitch    bool          `json:"must_switch"` // The active pokemon fainted, a ChangePokemon has to replace it before the turn goes on
 }
 
 type MatchFoundResponse struct {
 	BattleID     string           `json:"battle_id"`
 	YourInfo     PlayerBattleInfo `json:"your_info"`
 	OpponentInfo PlayerBattleInfo `json:"opponent_info"`
+	Ranked       bool             `json:"ranked,omitempty"`
 }
 
 type QueueJoinedResponse struct {
-	Message   string `json:"message"`
-	QueueSize int    `json:"queue_size"`
+	Message              string            `json:"message"`
+	QueueSize            int               `json:"queue_size"`
+	Ranked               bool              `json:"ranked,omitempty"`
+	Rating               int32             `json:"rating,omitempty"`
+	SearchWindow         *SearchWindowInfo `json:"search_window,omitempty"`          // only for the ranked queue
+	EstimatedWaitSeconds *int              `json:"estimated_wait_seconds,omitempty"` // only for the ranked queue
 }
 
-func (h *Handler) handleMatch(conn *Connection) {
+type QueueLeftResponse struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"` // see QUEUE_LEFT_*
+}
+
+// SearchWindowInfo is the range of ratings a ranked player can currently be matched with.
+// It widens the longer the player waits.
+type SearchWindowInfo struct {
+	MinRating int32 `json:"min_rating"`
+	MaxRating int32 `json:"max_rating"`
+}
+
+type MatchRequestPayload struct {
+	Ranked bool `json:"ranked"`
+}
+
+// How often waiting players are checked for ranked matches and for waiting too long
+const MATCHMAKING_INTERVAL = time.Second
+
+// Reasons a player leaves the matchmaking queue without a battle
+const (
+	QUEUE_LEFT_CANCELLED = "cancelled"
+	QUEUE_LEFT_TIMEOUT   = "timeout"
+)
+
+func (h *Handler) handleMatch(conn *Connection, msg Message) {
+	var payload MatchRequestPayload
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields,
+				map[string]string{"error": "Invalid payload"})
+			return
+		}
+	}
+
+	if payload.Ranked {
+		h.handleRankedMatch(conn, msg)
+		return
+	}
+
 	// Try to match the player
-	opponent := h.MatchmakingService.EnterQueue(conn.PlayerID, conn.Username)
+	opponent, err := h.MatchmakingService.EnterQueue(conn.PlayerID, conn.Username)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
 
 	// MATCH FOUND
+	// Opponent is player1 (first in queue), conn is player2 (second in queue)
+	if opponent != nil {
+		h.startBattle(*opponent, matchmaking_s.QueuedPlayer{PlayerID: conn.PlayerID, Username: conn.Username}, false)
+		return
+	}
+
+	// No match found, player added to queue
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.QueueJoined, QueueJoinedResponse{
+		Message:   "Joined matchmaking queue, waiting for opponent...",
+		QueueSize: h.MatchmakingService.GetQueueSize(),
+	})
+}
+
+// handleRankedMatch puts the player in the ranked queue, where they are matched
+// with players close to their rating. Ratings are kept by username, so players
+// whose name is also used by someone else connected can't play ranked.
+func (h *Handler) handleRankedMatch(conn *Connection, msg Message) {
+	if h.usernameShared(conn) {
+		err := fmt.Errorf("username %s is used by another connected player", conn.Username)
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": "Your username is used by another connected player, pick another one to play ranked"})
+		return
+	}
+
+	rating, err := h.BattleService.GetRating(context.Background(), conn.PlayerID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	opponent, window, err := h.MatchmakingService.EnterRankedQueue(conn.PlayerID, conn.Username, rating)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
 	if opponent != nil {
-		ctx := context.Background()
-
-		// CREATE BATTLE IN DATABASE
-		// Opponent is player1 (first in queue), conn is player2 (second in queue)
-		battleInfo, err := h.BattleService.CreateBattle(ctx, opponent.PlayerID, conn.PlayerID)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("player1_id", opponent.PlayerID.String()).
-				Str("player2_id", conn.PlayerID.String()).
-				Msg("Failed to create battle")
-
-			// Send error to both players
-			conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
+		h.startBattle(*opponent, matchmaking_s.QueuedPlayer{PlayerID: conn.PlayerID, Username: conn.Username, Rating: rating}, true)
+		return
+	}
+
+	estimatedWait := int(h.MatchmakingService.EstimatedRankedWait().Round(time.Second).Seconds())
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.QueueJoined, QueueJoinedResponse{
+		Message:   "Joined ranked queue, waiting for opponent...",
+		QueueSize: h.MatchmakingService.GetRankedQueueSize(),
+		Ranked:    true,
+		Rating:    rating,
+		SearchWindow: &SearchWindowInfo{
+			MinRating: window.MinRating,
+			MaxRating: window.MaxRating,
+		},
+		EstimatedWaitSeconds: &estimatedWait,
+	})
+}
+
+// runMatchmaking periodically starts the battles of ranked players that can be matched
+// now that their search windows widened, and removes the players that waited too long
+func (h *Handler) runMatchmaking() {
+	ticker := time.NewTicker(MATCHMAKING_INTERVAL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, match := range h.MatchmakingService.MatchWaitingRanked() {
+			h.startBattle(match.Player1, match.Player2, true)
+		}
+
+		if h.QueueMaxWait <= 0 {
+			continue
+		}
+		for _, player := range h.MatchmakingService.RemoveExpired(h.QueueMaxWait) {
+			err := h.SendToPlayer(player.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.QueueLeft, QueueLeftResponse{
+				Message: "No opponent found in time, left matchmaking queue",
+				Reason:  QUEUE_LEFT_TIMEOUT,
+			}))
+			if err != nil {
+				log.Debug().Err(err).Str("player_id", player.PlayerID.String()).Msg("Failed to notify queue timeout")
+			}
+		}
+	}
+}
+
+// handleCancelMatch takes the player out of the matchmaking queue
+func (h *Handler) handleCancelMatch(conn *Connection, msg Message) {
+	if !h.MatchmakingService.RemoveFromQueue(conn.PlayerID) {
+		err := fmt.Errorf("not in matchmaking queue")
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.QueueLeft, QueueLeftResponse{
+		Message: "Left matchmaking queue",
+		Reason:  QUEUE_LEFT_CANCELLED,
+	})
+}
+
+// startBattle creates the battle of two matched players and lets both of them know
+func (h *Handler) startBattle(player1, player2 matchmaking_s.QueuedPlayer, ranked bool) {
+	ctx := context.Background()
+
+	// CREATE BATTLE IN DATABASE
+	battleInfo, err := h.BattleService.CreateBattle(ctx, player1.PlayerID, player2.PlayerID, ranked)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("player1_id", player1.PlayerID.String()).
+			Str("player2_id", player2.PlayerID.String()).
+			Msg("Failed to create battle")
+
+		// Send error to both players
+		for _, player := range []matchmaking_s.QueuedPlayer{player1, player2} {
+			h.SendToPlayer(player.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
 				Message: "Failed to create battle",
 				Code:    500,
 				Details: map[string]string{"error": "Could not start battle"},
-			})
-			return
+			}))
 		}
+		return
+	}
 
-		// PREPARE MESSAGES FOR SENDING
-		player1Team := make([]PokemonInfo, len(battleInfo.Player1Team))
-		for i, poke := range battleInfo.Player1Team {
-			player1Team[i] = PokemonInfo{
-				SpeciesID: int(poke.PokemonSpeciesID.Int32),
-				Position:  poke.Position,
-				CurrentHP: poke.CurrentHp,
-				IsFainted: poke.IsFainted.Bool,
-			}
-		}
+	// REGISTER BATTLE
+	// Players are taken from the battle record so only they can act in it
+	h.mu.RLock()
+	player1Conn, player1Exists := h.Connections[battleInfo.Player1ID]
+	player2Conn, player2Exists := h.Connections[battleInfo.Player2ID]
+	h.mu.RUnlock()
+	if !player1Exists {
+		player1Conn = &Connection{PlayerID: player1.PlayerID, Username: player1.Username}
+	}
+	if !player2Exists {
+		player2Conn = &Connection{PlayerID: player2.PlayerID, Username: player2.Username}
+	}
+	h.Battles.Add(&ActiveBattle{
+		BattleID: battleInfo.BattleID,
+		Player1:  player1Conn,
+		Player2:  player2Conn,
+		Clock:    h.newTurnClock(battleInfo.BattleID),
+	})
+
+	// PREPARE MESSAGES FOR SENDING
+	player1Info := PlayerBattleInfo{
+		PlayerID:      player1.PlayerID.String(),
+		Username:      player1.Username,
+		Team:          buildTeamInfo(battleInfo.Player1Team),
+		ActivePokemon: 1,
+	}
+	player2Info := PlayerBattleInfo{
+		PlayerID:      player2.PlayerID.String(),
+		Username:      player2.Username,
+		Team:          buildTeamInfo(battleInfo.Player2Team),
+		ActivePokemon: 1,
+	}
+
+	// SEND MESSAGES
+	err = h.SendToPlayer(player1.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.MatchFound, MatchFoundResponse{
+		BattleID:     battleInfo.BattleID.String(),
+		YourInfo:     player1Info,
+		OpponentInfo: player2Info,
+		Ranked:       battleInfo.Ranked,
+	}))
+	if err != nil {
+		log.Warn().Err(err).Str("player_id", player1.PlayerID.String()).Msg("Failed to notify player of match")
+	}
+
+	err = h.SendToPlayer(player2.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.MatchFound, MatchFoundResponse{
+		BattleID:     battleInfo.BattleID.String(),
+		YourInfo:     player2Info,
+		OpponentInfo: player1Info,
+		Ranked:       battleInfo.Ranked,
+	}))
+	if err != nil {
+		log.Warn().Err(err).Str("player_id", player2.PlayerID.String()).Msg("Failed to notify player of match")
+	}
+
+	log.Info().
+		Str("battle_id", battleInfo.BattleID.String()).
+		Str("player1", player1.Username).
+		Str("player2", player2.Username).
+		Bool("ranked", battleInfo.Ranked).
+		Msg("Battle started and players notified")
+}
 
-		player2Team := make([]PokemonInfo, len(battleInfo.Player2Team))
-		for i, poke := range battleInfo.Player2Team {
-			player2Team[i] = PokemonInfo{
-				SpeciesID: int(poke.PokemonSpeciesID.Int32),
-				Position:  poke.Position,
-				CurrentHP: poke.CurrentHp,
-				IsFainted: poke.IsFainted.Bool,
+// buildTeamInfo converts a team and its move PP into the client representation
+func buildTeamInfo(team []engine.Pokemon) []PokemonInfo {
+	info := make([]PokemonInfo, len(team))
+	for i, poke := range team {
+		moves := make([]MoveInfo, len(poke.Moves))
+		for j, move := range poke.Moves {
+			moves[j] = MoveInfo{
+				MoveID: move.MoveID,
+				Name:   move.Name,
+				PP:     move.PP,
+				MaxPP:  move.MaxPP,
 			}
 		}
-
-		// SEND MESSAGES
-		// opponent is player1, conn is player2
-
-		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.MatchFound, MatchFoundResponse{
-			BattleID: battleInfo.BattleID.String(),
-			YourInfo: PlayerBattleInfo{
-				PlayerID:      conn.PlayerID.String(),
-				Username:      conn.Username,
-				Team:          player2Team,
-				ActivePokemon: 1,
-			},
-			OpponentInfo: PlayerBattleInfo{
-				PlayerID:      opponent.PlayerID.String(),
-				Username:      opponent.Username,
-				Team:          player1Team,
-				ActivePokemon: 1,
-			},
-		})
-
-		err = h.SendToPlayer(opponent.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.MatchFound, MatchFoundResponse{
-			BattleID: battleInfo.BattleID.String(),
-			YourInfo: PlayerBattleInfo{
-				PlayerID:      opponent.PlayerID.String(),
-				Username:      opponent.Username,
-				Team:          player1Team,
-				ActivePokemon: 1,
-			},
-			OpponentInfo: PlayerBattleInfo{
-				PlayerID:      conn.PlayerID.String(),
-				Username:      conn.Username,
-				Team:          player2Team,
-				ActivePokemon: 1,
-			},
-		}))
-
-		if err != nil {
-			log.Warn().
-				Err(err).
-				Str("opponent_id", opponent.PlayerID.String()).
-				Msg("Failed to notify opponent of match")
+		stages := make(map[string]int32, len(engine.Stats))
+		for _, stat := range engine.Stats {
+			stages[stat] = poke.Stages[stat]
+		}
+		info[i] = PokemonInfo{
+			SpeciesID: int(poke.SpeciesID),
+			Position:  poke.Position,
+			CurrentHP: poke.HP,
+			IsFainted: poke.Fainted,
+			Status:    poke.Status,
+			Stages:    stages,
+			Moves:     moves,
 		}
-
-		log.Info().
-			Str("battle_id", battleInfo.BattleID.String()).
-			Str("player1", conn.Username).
-			Str("player2", opponent.Username).
-			Msg("Battle started and players notified")
-	} else {
-		// No match found, player added to queue
-		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.QueueJoined, QueueJoinedResponse{
-			Message:   "Joined matchmaking queue, waiting for opponent...",
-			QueueSize: h.MatchmakingService.GetQueueSize(),
-		})
 	}
+	return info
 }
@@ -0,0 +1,110 @@
+package ws_h
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/DanielRasho/PokeSocket/internal/services/matchmaking_s"
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/rs/zerolog/log"
+)
+
+// Reasons a private room closes without a battle
+const (
+	ROOM_CLOSED_CANCELLED = "cancelled"
+	ROOM_CLOSED_EXPIRED   = "expired"
+)
+
+type JoinRoomRequest struct {
+	Code string `json:"code" validate:"required,len=6"`
+}
+
+type RoomCreatedResponse struct {
+	Code             string `json:"code"` // share it with the player to battle
+	ExpiresInSeconds int    `json:"expires_in_seconds"`
+}
+
+type RoomClosedResponse struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"` // see ROOM_CLOSED_*
+}
+
+// handleCreateRoom opens a private room that only a player with its code can join
+func (h *Handler) handleCreateRoom(conn *Connection, msg Message) {
+	code, err := h.MatchmakingService.CreateRoom(conn.PlayerID, conn.Username)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	time.AfterFunc(h.RoomTimeout, func() {
+		h.expireRoom(code)
+	})
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.RoomCreated, RoomCreatedResponse{
+		Code:             code,
+		ExpiresInSeconds: int(h.RoomTimeout.Seconds()),
+	})
+}
+
+// handleJoinRoom starts the battle between the player and the host of the room
+func (h *Handler) handleJoinRoom(conn *Connection, msg Message) {
+	var payload JoinRoomRequest
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields,
+			map[string]string{"error": "Invalid payload"})
+		return
+	}
+
+	if details, err := utils.ValidateStruct(&h.Validator, payload); err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields, details)
+		return
+	}
+
+	host, err := h.MatchmakingService.JoinRoom(payload.Code, conn.PlayerID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	// The host is player1, as the first to wait in the matchmaking queue
+	h.startBattle(*host, matchmaking_s.QueuedPlayer{PlayerID: conn.PlayerID, Username: conn.Username}, false)
+}
+
+// handleCancelRoom closes the room of the player before anyone joins it
+func (h *Handler) handleCancelRoom(conn *Connection, msg Message) {
+	code, err := h.MatchmakingService.CancelRoom(conn.PlayerID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.RoomClosed, RoomClosedResponse{
+		Code:   code,
+		Reason: ROOM_CLOSED_CANCELLED,
+	})
+}
+
+// expireRoom closes a room nobody joined in time and lets its host know
+func (h *Handler) expireRoom(code string) {
+	host, expired := h.MatchmakingService.ExpireRoom(code)
+	if !expired {
+		return
+	}
+
+	log.Info().
+		Str("player_id", host.PlayerID.String()).
+		Str("code", code).
+		Msg("Private room expired")
+
+	err := h.SendToPlayer(host.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.RoomClosed, RoomClosedResponse{
+		Code:   code,
+		Reason: ROOM_CLOSED_EXPIRED,
+	}))
+	if err != nil {
+		log.Debug().Err(err).Str("player_id", host.PlayerID.String()).Msg("Failed to notify room expiry")
+	}
+}
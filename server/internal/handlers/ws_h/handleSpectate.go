@@ -0,0 +1,180 @@
+package ws_h
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+type SpectateRequest struct {
+	BattleID string `json:"battle_id" validate:"required,uuid"`
+}
+
+// ActiveBattleInfo describes a battle that can be spectated
+type ActiveBattleInfo struct {
+	BattleID   string `json:"battle_id"`
+	Player1    string `json:"player1"` // username
+	Player2    string `json:"player2"` // username
+	Spectators int    `json:"spectators"`
+}
+
+type BattleListResponse struct {
+	Battles []ActiveBattleInfo `json:"battles"`
+}
+
+// SpectatorStateResponse is the battle state as seen by a spectator, who takes no side
+type SpectatorStateResponse struct {
+	BattleID    string            `json:"battle_id"`
+	Turn        int32             `json:"turn"`
+	Message     string            `json:"message"`
+	Events      []BattleEventInfo `json:"events"` // in the order they happened
+	Player1Info PlayerBattleInfo  `json:"player1_info"`
+	Player2Info PlayerBattleInfo  `json:"player2_info"`
+	BattleEnded bool              `json:"battle_ended,omitempty"`
+	Winner      string            `json:"winner,omitempty"`     // player_id of winner
+	EndReason   string            `json:"end_reason,omitempty"` // see battle_s.END_REASON_*
+}
+
+type SpectateStoppedResponse struct {
+	BattleID string `json:"battle_id"`
+}
+
+// handleListBattles sends the battles being played that can be spectated
+func (h *Handler) handleListBattles(conn *Connection, msg Message) {
+	battles := []ActiveBattleInfo{}
+	for _, battle := range h.Battles.List() {
+		if battle.Has(conn.PlayerID) {
+			continue
+		}
+		battles = append(battles, ActiveBattleInfo{
+			BattleID:   battle.BattleID.String(),
+			Player1:    battle.Player1.Username,
+			Player2:    battle.Player2.Username,
+			Spectators: len(h.Battles.Spectators(battle)),
+		})
+	}
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.BattleList, BattleListResponse{Battles: battles})
+}
+
+// handleSpectate subscribes the player to a battle and sends its current state.
+// From then on they get every turn of it until it ends.
+func (h *Handler) handleSpectate(conn *Connection, msg Message) {
+	battleUUID, ok := h.parseSpectateRequest(conn, msg)
+	if !ok {
+		return
+	}
+
+	battle, err := h.Battles.Watch(battleUUID, conn.PlayerID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	battleState, err := h.BattleService.GetBattleState(context.Background(), battleUUID)
+	if err != nil {
+		h.Battles.Unwatch(battleUUID, conn.PlayerID)
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.SpectatorState, spectatorStateResponse(battle, battleState))
+
+	log.Info().
+		Str("player_id", conn.PlayerID.String()).
+		Str("battle_id", battleUUID.String()).
+		Msg("Spectator joined")
+}
+
+// handleStopSpectate unsubscribes the player from a battle they watch
+func (h *Handler) handleStopSpectate(conn *Connection, msg Message) {
+	battleUUID, ok := h.parseSpectateRequest(conn, msg)
+	if !ok {
+		return
+	}
+
+	if !h.Battles.Unwatch(battleUUID, conn.PlayerID) {
+		err := fmt.Errorf("you are not spectating this battle")
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.SpectateStopped, SpectateStoppedResponse{
+		BattleID: battleUUID.String(),
+	})
+}
+
+// parseSpectateRequest reads the battle of a Spectate or StopSpectate message,
+// answering with an error if it is not valid
+func (h *Handler) parseSpectateRequest(conn *Connection, msg Message) (pgtype.UUID, bool) {
+	var battleUUID pgtype.UUID
+
+	var payload SpectateRequest
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields,
+			map[string]string{"error": "Invalid payload"})
+		return battleUUID, false
+	}
+	if details, err := utils.ValidateStruct(&h.Validator, payload); err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields, details)
+		return battleUUID, false
+	}
+
+	if scanErr := battleUUID.Scan(payload.BattleID); scanErr != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, scanErr, msg, utils.BadRequest,
+			map[string]string{"error": "Invalid UUID format"})
+		return battleUUID, false
+	}
+	return battleUUID, true
+}
+
+// notifySpectators sends the battle state to everyone watching the battle.
+// Spectators never hold up the battle: if their buffer is full the update is dropped.
+func (h *Handler) notifySpectators(battle *ActiveBattle, battleState *battle_s.BattleStateResult) {
+	spectators := h.Battles.Spectators(battle)
+	if len(spectators) == 0 {
+		return
+	}
+	message := NewMessage(SERVER_MESSAGE_TYPE.SpectatorState, spectatorStateResponse(battle, battleState))
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, playerID := range spectators {
+		conn, exists := h.Connections[playerID]
+		if !exists {
+			continue // Disconnected, they get the next update if they come back
+		}
+		select {
+		case conn.Send <- message:
+		default:
+			log.Warn().
+				Str("player_id", playerID.String()).
+				Str("battle_id", battle.BattleID.String()).
+				Msg("Spectator buffer full, update dropped")
+		}
+	}
+}
+
+// spectatorStateResponse builds the battle state from a neutral point of view
+func spectatorStateResponse(battle *ActiveBattle, battleState *battle_s.BattleStateResult) SpectatorStateResponse {
+	player1Response, _ := battleStateResponses(battle.Player1, battle.Player2, battleState)
+	return SpectatorStateResponse{
+		BattleID:    player1Response.BattleID,
+		Turn:        player1Response.Turn,
+		Message:     player1Response.Message,
+		Events:      player1Response.Events,
+		Player1Info: player1Response.YourInfo,
+		Player2Info: player1Response.OpponentInfo,
+		BattleEnded: player1Response.BattleEnded,
+		Winner:      player1Response.Winner,
+		EndReason:   player1Response.EndReason,
+	}
+}
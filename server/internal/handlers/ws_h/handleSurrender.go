@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/DanielRasho/PokeSocket/utils"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog/log"
 )
@@ -12,49 +13,54 @@ type SurrenderRequest struct {
 	BattleID string `json:"battle_id" validate:"required,uuid"`
 }
 
-// TODO: To implement
+// handleSurrender ends the battle in favour of the opponent and notifies both players
 func (h *Handler) handleSurrender(conn *Connection, msg Message) {
 	var payload SurrenderRequest
 	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
-		log.Error().Err(err).Msg("Failed to parse surrender request")
-		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
-			Message: "Invalid surrender request",
-			Code:    400,
-			Details: map[string]string{"error": "Invalid payload"},
-		})
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.InvalidFields,
+			map[string]string{"error": "Invalid payload"})
 		return
 	}
 
-	// TODO: Implement surrender logic
-	// - Validate battle exists
-	// - Verify player is in the battle
-	// - Set winner as the opponent
-	// - Notify both players of the result
-
 	ctx := context.Background()
 
 	// Parse battle ID
 	var battleUUID pgtype.UUID
 	if scanErr := battleUUID.Scan(payload.BattleID); scanErr != nil {
-		log.Error().Err(scanErr).Msg("Invalid battle UUID")
-		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
-			Message: "Invalid battle ID",
-			Code:    400,
-			Details: map[string]string{"error": "Invalid UUID format"},
-		})
+		sendAndLogError(conn.Ctx, conn.Conn, scanErr, msg, utils.BadRequest,
+			map[string]string{"error": "Invalid UUID format"})
 		return
 	}
 
-	// DELETE BATTLE FROM DATABASE
-	if deleteErr := h.BattleService.DeleteBattle(ctx, battleUUID); deleteErr != nil {
-		log.Error().
-			Err(deleteErr).
-			Str("battle_id", payload.BattleID).
-			Msg("Failed to delete battle after surrender")
+	battle, err := h.battleOf(conn, battleUUID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	battleState, err := h.BattleService.Surrender(ctx, battleUUID, conn.PlayerID)
+	if err != nil {
+		sendAndLogError(conn.Ctx, conn.Conn, err, msg, utils.BadRequest,
+			map[string]string{"error": err.Error()})
+		return
+	}
+
+	h.Battles.Remove(battleUUID)
+	h.notifySpectators(battle, battleState)
+
+	opponentConn := battle.Opponent(conn.PlayerID)
+	yourResponse, opponentResponse := battleStateResponses(conn, opponentConn, battleState)
+
+	conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.BattleEnded, yourResponse)
+	err = h.SendToPlayer(opponentConn.PlayerID, NewMessage(SERVER_MESSAGE_TYPE.BattleEnded, opponentResponse))
+	if err != nil {
+		log.Warn().Err(err).Str("opponent_id", opponentConn.PlayerID.String()).Msg("Failed to notify opponent")
 	}
 
 	log.Info().
 		Str("player_id", conn.PlayerID.String()).
 		Str("battle_id", payload.BattleID).
-		Msg("Player surrendered, battle deleted")
+		Str("winner_id", battleState.WinnerID.String()).
+		Msg("Player surrendered")
 }
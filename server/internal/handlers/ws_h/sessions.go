@@ -0,0 +1,173 @@
+package ws_h
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DanielRasho/PokeSocket/utils"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/rs/zerolog/log"
+)
+
+// Session is what a player gets back when they reconnect with their resume token
+type Session struct {
+	PlayerID PlayerID
+	Username string
+	Pokemons []int
+	expiry   *time.Timer // Running while the player is disconnected
+}
+
+type ReconnectRequest struct {
+	ResumeToken string `json:"resume_token" validate:"required"`
+}
+
+// newResumeToken returns a random token that identifies a session
+func newResumeToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate resume token: %w", err)
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// handleReconnect attaches a new websocket to the player of a resume token,
+// as long as the grace period after their disconnection has not run out
+func (h *Handler) handleReconnect(msg Message, ctx context.Context, conn *websocket.Conn) (*Connection, error) {
+	var payload ReconnectRequest
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if details, err := utils.ValidateStruct(&h.Validator, payload); err != nil {
+		return nil, &utils.VerificationError{
+			Err:       err,
+			UserError: details,
+			Code:      utils.InvalidFields,
+		}
+	}
+
+	h.mu.Lock()
+	session, exists := h.Sessions[payload.ResumeToken]
+	if !exists {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("invalid or expired resume token")
+	}
+	if session.expiry != nil {
+		// The player is being removed already
+		if !session.expiry.Stop() {
+			h.mu.Unlock()
+			return nil, fmt.Errorf("invalid or expired resume token")
+		}
+		session.expiry = nil
+	}
+
+	// The previous socket may not have noticed it was dropped yet
+	if previous, exists := h.Connections[session.PlayerID]; exists {
+		delete(h.Connections, session.PlayerID)
+		previous.Cancel()
+		previous.Conn.Close(websocket.StatusPolicyViolation, "Reconnected from another socket")
+	}
+	h.mu.Unlock()
+
+	connection := NewConnection(session.PlayerID, session.Username, session.Pokemons, conn, ctx)
+	connection.ResumeToken = payload.ResumeToken
+	h.Battles.Reattach(connection)
+	h.MatchmakingService.SetAway(session.PlayerID, false)
+
+	err := wsjson.Write(ctx, conn, NewMessage(
+		SERVER_MESSAGE_TYPE.AcceptConnection,
+		ClientConnectResponse{
+			Username:    session.Username,
+			Id:          session.PlayerID,
+			ResumeToken: payload.ResumeToken,
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept reconnection: %w", err)
+	}
+
+	log.Info().
+		Str("player_id", session.PlayerID.String()).
+		Str("username", session.Username).
+		Msg("Player reconnected")
+
+	h.notifyOpponents(session.PlayerID, true)
+
+	return connection, nil
+}
+
+// detachConnection is called when a socket is dropped. The player keeps their
+// battles and queue slot during the grace period, after it they are removed.
+func (h *Handler) detachConnection(conn *Connection) {
+	h.mu.Lock()
+
+	conn.Close()
+
+	// The player already came back on another socket
+	if current, exists := h.Connections[conn.PlayerID]; !exists || current != conn {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.Connections, conn.PlayerID)
+	h.MatchmakingService.SetAway(conn.PlayerID, true)
+
+	session, exists := h.Sessions[conn.ResumeToken]
+	if !exists {
+		h.mu.Unlock()
+		return
+	}
+	token := conn.ResumeToken
+	session.expiry = time.AfterFunc(h.ReconnectGracePeriod, func() {
+		h.expireSession(token)
+	})
+	h.mu.Unlock()
+
+	log.Info().
+		Str("player_id", conn.PlayerID.String()).
+		Str("username", conn.Username).
+		Dur("grace_period", h.ReconnectGracePeriod).
+		Msg("Player disconnected, waiting for reconnection")
+
+	// Opponents are told how long they have to wait before winning
+	h.notifyOpponents(conn.PlayerID, false)
+}
+
+// expireSession removes a player that did not reconnect in time
+func (h *Handler) expireSession(token string) {
+	h.mu.Lock()
+	session, exists := h.Sessions[token]
+	if !exists || session.expiry == nil {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.Sessions, token)
+	h.mu.Unlock()
+
+	log.Info().
+		Str("player_id", session.PlayerID.String()).
+		Str("username", session.Username).
+		Msg("Player did not reconnect in time")
+
+	h.RemoveConnection(session.PlayerID)
+}
+
+// sendBattleStates sends the current state of every battle of the player
+func (h *Handler) sendBattleStates(conn *Connection) {
+	for _, battle := range h.Battles.ByPlayer(conn.PlayerID) {
+		battleState, err := h.BattleService.GetBattleState(context.Background(), battle.BattleID)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("battle_id", battle.BattleID.String()).
+				Msg("Failed to get battle state")
+			continue
+		}
+
+		yourResponse, _ := battleStateResponses(conn, battle.Opponent(conn.PlayerID), battleState)
+		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Status, yourResponse)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DanielRasho/PokeSocket/internal/config"
 	"github.com/DanielRasho/PokeSocket/internal/services/battle_s"
 	"github.com/DanielRasho/PokeSocket/internal/services/matchmaking_s"
 	"github.com/DanielRasho/PokeSocket/internal/services/users_s"
@@ -28,13 +29,21 @@ type Message struct {
 type PlayerID = pgtype.UUID
 
 type Handler struct {
-	DBClient           *pgxpool.Pool
-	Validator          validator.Validate
-	Connections        map[PlayerID]*Connection
-	mu                 sync.RWMutex // Protect concurrent access to Connections map
-	UserService        *users_s.UserService
-	MatchmakingService *matchmaking_s.MatchmakingService
-	BattleService      *battle_s.BattleService
+	DBClient             *pgxpool.Pool
+	Validator            validator.Validate
+	Connections          map[PlayerID]*Connection
+	Sessions             map[string]*Session // By resume token, outlive the connection during the grace period
+	mu                   sync.RWMutex        // Protect concurrent access to Connections and Sessions maps
+	Battles              *BattleRegistry
+	UserService          *users_s.UserService
+	MatchmakingService   *matchmaking_s.MatchmakingService
+	BattleService        *battle_s.BattleService
+	ReconnectGracePeriod time.Duration
+	TurnTimeout          time.Duration
+	TurnClockTick        time.Duration
+	TurnTimeoutAction    string
+	RoomTimeout          time.Duration
+	QueueMaxWait         time.Duration
 }
 
 func NewHandler(
@@ -42,15 +51,25 @@ func NewHandler(
 	validator *validator.Validate,
 	userService *users_s.UserService,
 	matchmakingService *matchmaking_s.MatchmakingService,
-	battleService *battle_s.BattleService) http.HandlerFunc {
+	battleService *battle_s.BattleService,
+	gameConfig config.GameConfig) http.HandlerFunc {
 	h := Handler{
-		DBClient:           dbClient,
-		Validator:          *validator,
-		Connections:        make(map[pgtype.UUID]*Connection),
-		UserService:        userService,
-		MatchmakingService: matchmakingService,
-		BattleService:      battleService,
+		DBClient:             dbClient,
+		Validator:            *validator,
+		Connections:          make(map[pgtype.UUID]*Connection),
+		Sessions:             make(map[string]*Session),
+		Battles:              NewBattleRegistry(),
+		UserService:          userService,
+		MatchmakingService:   matchmakingService,
+		BattleService:        battleService,
+		ReconnectGracePeriod: gameConfig.ReconnectGracePeriod,
+		TurnTimeout:          gameConfig.TurnTimeout,
+		TurnClockTick:        gameConfig.TurnClockTick,
+		TurnTimeoutAction:    gameConfig.TurnTimeoutAction,
+		RoomTimeout:          gameConfig.RoomTimeout,
+		QueueMaxWait:         gameConfig.QueueMaxWait,
 	}
+	go h.runMatchmaking()
 	return h.HandleRequest
 }
 
@@ -59,47 +78,72 @@ func (h *Handler) AddConnection(conn *Connection) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.Connections[conn.PlayerID] = conn
+	if _, exists := h.Sessions[conn.ResumeToken]; !exists {
+		h.Sessions[conn.ResumeToken] = &Session{
+			PlayerID: conn.PlayerID,
+			Username: conn.Username,
+			Pokemons: conn.Pokemons,
+		}
+	}
 	log.Debug().
 		Str("player_id", conn.PlayerID.String()).
 		Str("username", conn.Username).
 		Msg("Connection added")
 }
 
-// RemoveConnection safely removes and cleans up a connection
+// RemoveConnection removes the player for good: their battles are lost,
+// they leave the matchmaking queue and their user is deleted
 func (h *Handler) RemoveConnection(playerID PlayerID) {
+	// Battles left behind are lost, this must happen before the user is deleted
+	h.forfeitBattles(playerID, battle_s.END_REASON_DISCONNECT)
+	h.Battles.UnwatchAll(playerID)
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if conn, exists := h.Connections[playerID]; exists {
-		// Remove from matchmaking queue if they were waiting
-		h.MatchmakingService.RemoveFromQueue(playerID)
+	// Remove from matchmaking queue if they were waiting, or close their room
+	h.MatchmakingService.RemoveFromQueue(playerID)
+	h.MatchmakingService.CancelRoom(playerID)
 
-		// Delete user from database (will CASCADE delete team)
-		ctx := context.Background()
-		err := h.UserService.DeleteUser(ctx, playerID)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("player_id", playerID.String()).
-				Str("username", conn.Username).
-				Msg("Failed to delete user from database")
-		}
+	// Delete user from database (will CASCADE delete team)
+	ctx := context.Background()
+	err := h.UserService.DeleteUser(ctx, playerID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("player_id", playerID.String()).
+			Msg("Failed to delete user from database")
+	}
 
+	if conn, exists := h.Connections[playerID]; exists {
 		conn.Close() // Properly close the connection
 		delete(h.Connections, playerID)
-		log.Debug().
-			Str("player_id", playerID.String()).
-			Str("username", conn.Username).
-			Msg("Connection removed")
 	}
+	log.Debug().
+		Str("player_id", playerID.String()).
+		Msg("Connection removed")
+}
+
+// usernameShared reports whether another connected player uses the same username as conn
+func (h *Handler) usernameShared(conn *Connection) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for playerID, other := range h.Connections {
+		if playerID != conn.PlayerID && other.Username == conn.Username {
+			return true
+		}
+	}
+	return false
 }
 
 // SendToPlayer sends a message via the buffered channel
 func (h *Handler) SendToPlayer(playerID PlayerID, message Message) error {
+	// Hold the lock while sending so the connection can't be closed meanwhile
 	h.mu.RLock()
-	conn, exists := h.Connections[playerID]
-	h.mu.RUnlock()
+	defer h.mu.RUnlock()
 
+	conn, exists := h.Connections[playerID]
 	if !exists {
 		return fmt.Errorf("player not connected")
 	}
@@ -140,11 +184,20 @@ func (h *Handler) HandleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Register connection
-	connection, err := h.handleConnect(initialMsg, ctx, conn)
+	// Register connection, or give a dropped player their session back
+	var connection *Connection
+	reconnecting := initialMsg.Type == CLIENT_MESSAGE_TYPE.Reconnect
+	if reconnecting {
+		connection, err = h.handleReconnect(initialMsg, ctx, conn)
+	} else {
+		connection, err = h.handleConnect(initialMsg, ctx, conn)
+	}
 	if err != nil {
 		if verr, ok := err.(*utils.VerificationError); ok {
 			sendAndLogError(ctx, conn, err, initialMsg, utils.InvalidFields, verr.UserError)
+		} else if reconnecting {
+			sendAndLogError(ctx, conn, err, initialMsg, utils.InvalidFields,
+				map[string]string{"error": err.Error()})
 		} else {
 			sendAndLogError(ctx, conn, err, initialMsg, utils.InvalidFields,
 				map[string]string{"type": "Error creating user"})
@@ -153,12 +206,16 @@ func (h *Handler) HandleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	h.AddConnection(connection)
-	defer h.RemoveConnection(connection.PlayerID)
+	defer h.detachConnection(connection)
 
 	// Start background goroutines
 	go connection.writePump()
 	go connection.pingConnection()
 
+	if reconnecting {
+		h.sendBattleStates(connection)
+	}
+
 	// HANDLE OTHER MESSAGES (blocks until disconnect)
 	h.processMessages(connection)
 }
@@ -183,43 +240,80 @@ func (h *Handler) processMessages(conn *Connection) {
 			return
 		}
 
-		// HANDLE MESSAGE - now using the channel instead of direct writes
-		switch msg.Type {
-		case CLIENT_MESSAGE_TYPE.Connect:
-			conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
-				Message: "Already connected",
-				Code:    utils.InvalidFields.StatusCode,
-				Details: map[string]string{"type": "Already connected"},
-			})
-
-		case CLIENT_MESSAGE_TYPE.Status:
-			conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Status, map[string]string{
-				"status":   "connected",
-				"username": conn.Username,
-			})
-
-		case CLIENT_MESSAGE_TYPE.Match:
-			log.Debug().Str("username", conn.Username).Msg("Match request received")
-			h.handleMatch(conn)
-
-		case CLIENT_MESSAGE_TYPE.Attack:
-			log.Debug().Str("username", conn.Username).Msg("Attack received")
-			h.handleAttack(conn, msg)
-
-		case CLIENT_MESSAGE_TYPE.ChangePokemon:
-			log.Debug().Str("username", conn.Username).Msg("Change Pokemon received")
-			h.handleChangePokemon(conn, msg)
-
-		case CLIENT_MESSAGE_TYPE.Surrender:
-			log.Debug().Str("username", conn.Username).Msg("Surrender received")
-			// TODO: Handle surrender
-
-		default:
-			conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
-				Message: "Unknown message type",
-				Code:    utils.InvalidFields.StatusCode,
-				Details: map[string]string{"received_type": fmt.Sprint(msg.Type)},
-			})
-		}
+		h.dispatch(conn, msg)
+	}
+}
+
+// dispatch runs the handler of a client message. Bots act through it as well.
+func (h *Handler) dispatch(conn *Connection, msg Message) {
+	// HANDLE MESSAGE - now using the channel instead of direct writes
+	switch msg.Type {
+	case CLIENT_MESSAGE_TYPE.Connect, CLIENT_MESSAGE_TYPE.Reconnect:
+		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
+			Message: "Already connected",
+			Code:    utils.InvalidFields.StatusCode,
+			Details: map[string]string{"type": "Already connected"},
+		})
+
+	case CLIENT_MESSAGE_TYPE.Status:
+		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Status, map[string]string{
+			"status":   "connected",
+			"username": conn.Username,
+		})
+
+	case CLIENT_MESSAGE_TYPE.Match:
+		log.Debug().Str("username", conn.Username).Msg("Match request received")
+		h.handleMatch(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.CancelMatch:
+		log.Debug().Str("username", conn.Username).Msg("Cancel match received")
+		h.handleCancelMatch(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.CreateRoom:
+		log.Debug().Str("username", conn.Username).Msg("Create room received")
+		h.handleCreateRoom(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.JoinRoom:
+		log.Debug().Str("username", conn.Username).Msg("Join room received")
+		h.handleJoinRoom(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.CancelRoom:
+		log.Debug().Str("username", conn.Username).Msg("Cancel room received")
+		h.handleCancelRoom(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.Attack:
+		log.Debug().Str("username", conn.Username).Msg("Attack received")
+		h.handleAttack(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.ChangePokemon:
+		log.Debug().Str("username", conn.Username).Msg("Change Pokemon received")
+		h.handleChangePokemon(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.Surrender:
+		log.Debug().Str("username", conn.Username).Msg("Surrender received")
+		h.handleSurrender(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.PlayBot:
+		log.Debug().Str("username", conn.Username).Msg("Play bot received")
+		h.handlePlayBot(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.ListBattles:
+		log.Debug().Str("username", conn.Username).Msg("List battles received")
+		h.handleListBattles(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.Spectate:
+		log.Debug().Str("username", conn.Username).Msg("Spectate received")
+		h.handleSpectate(conn, msg)
+
+	case CLIENT_MESSAGE_TYPE.StopSpectate:
+		log.Debug().Str("username", conn.Username).Msg("Stop spectate received")
+		h.handleStopSpectate(conn, msg)
+
+	default:
+		conn.Send <- NewMessage(SERVER_MESSAGE_TYPE.Error, ErrorResponse{
+			Message: "Unknown message type",
+			Code:    utils.InvalidFields.StatusCode,
+			Details: map[string]string{"received_type": fmt.Sprint(msg.Type)},
+		})
 	}
 }
@@ -17,6 +17,15 @@ var CLIENT_MESSAGE_TYPE = struct {
 	Surrender     int
 	Status        int
 	Match         int
+	Reconnect     int
+	CreateRoom    int
+	JoinRoom      int
+	CancelRoom    int
+	CancelMatch   int
+	PlayBot       int
+	ListBattles   int
+	Spectate      int
+	StopSpectate  int
 }{
 	Connect:       1,
 	Attack:        2,
@@ -24,6 +33,15 @@ var CLIENT_MESSAGE_TYPE = struct {
 	Surrender:     4,
 	Status:        5,
 	Match:         6,
+	Reconnect:     7,
+	CreateRoom:    8,
+	JoinRoom:      9,
+	CancelRoom:    10,
+	CancelMatch:   11,
+	PlayBot:       12,
+	ListBattles:   13,
+	Spectate:      14,
+	StopSpectate:  15,
 }
 
 var SERVER_MESSAGE_TYPE = struct {
@@ -36,6 +54,14 @@ var SERVER_MESSAGE_TYPE = struct {
 	Error            int
 	MatchFound       int
 	QueueJoined      int
+	TurnResult       int
+	TurnClock        int
+	RoomCreated      int
+	RoomClosed       int
+	QueueLeft        int
+	BattleList       int
+	SpectatorState   int
+	SpectateStopped  int
 }{
 	AcceptConnection: 50,
 	Attack:           51,
@@ -46,6 +72,14 @@ var SERVER_MESSAGE_TYPE = struct {
 	Error:            56,
 	MatchFound:       57,
 	QueueJoined:      58,
+	TurnResult:       59,
+	TurnClock:        60,
+	RoomCreated:      61,
+	RoomClosed:       62,
+	QueueLeft:        63,
+	BattleList:       64,
+	SpectatorState:   65,
+	SpectateStopped:  66,
 }
 
 // Helper function to create a message with any payload
@@ -93,6 +127,10 @@ func sendAndLogError(
 
 	LogErrorRequest(err, request.Type, response.StatusCode, request.Payload, msg.Payload)
 
+	// Bots have no websocket, their failed actions are only logged
+	if conn == nil {
+		return
+	}
 	wsjson.Write(ctx, conn, msg)
 }
 
@@ -0,0 +1,39 @@
+package battle_s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// BotAction chooses the action of a bot for the current turn of the battle,
+// level is one of engine.BOT_LEVEL_*
+func (s *BattleService) BotAction(ctx context.Context, battleID, botID pgtype.UUID, level string) (TurnAction, error) {
+	rules, err := s.battleEngine(ctx)
+	if err != nil {
+		return TurnAction{}, err
+	}
+
+	battle, err := s.DBQueries.GetBattle(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TurnAction{}, ErrBattleNotFound
+	}
+	if err != nil {
+		return TurnAction{}, fmt.Errorf("failed to get battle: %w", err)
+	}
+
+	state, err := loadState(ctx, s.DBQueries, battle)
+	if err != nil {
+		return TurnAction{}, err
+	}
+
+	action, err := rules.BotAction(state, botID, level, engine.SeededRoller(engine.NewBattleSeed(), state.Turn))
+	if err != nil {
+		return TurnAction{}, err
+	}
+	return TurnAction{Type: action.Type, MoveID: action.MoveID, Position: action.Position}, nil
+}
@@ -0,0 +1,125 @@
+package battle_s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// Reasons a battle can end for, stored in battle_results.end_reason
+const (
+	END_REASON_ALL_FAINTED = "all_fainted"
+	END_REASON_SURRENDER   = "surrender"
+	END_REASON_DISCONNECT  = "disconnect"
+	END_REASON_TIMEOUT     = "timeout"
+)
+
+// forfeitMessages describe why a player lost the battle without being knocked out
+var forfeitMessages = map[string]string{
+	END_REASON_SURRENDER:  "A player surrendered! The battle is over.",
+	END_REASON_DISCONNECT: "A player disconnected! The battle is over.",
+	END_REASON_TIMEOUT:    "A player ran out of time! The battle is over.",
+}
+
+// Surrender ends an active battle in favour of the opponent of the surrendering player
+func (s *BattleService) Surrender(ctx context.Context, battleID, playerID pgtype.UUID) (*BattleStateResult, error) {
+	return s.Forfeit(ctx, battleID, playerID, END_REASON_SURRENDER)
+}
+
+// Forfeit ends an active battle in favour of the opponent of playerID.
+// reason is one of END_REASON_SURRENDER, END_REASON_DISCONNECT or END_REASON_TIMEOUT.
+func (s *BattleService) Forfeit(ctx context.Context, battleID, playerID pgtype.UUID, reason string) (*BattleStateResult, error) {
+	message, ok := forfeitMessages[reason]
+	if !ok {
+		return nil, fmt.Errorf("unknown end reason %q", reason)
+	}
+
+	tx, err := s.DBClient.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.DBQueries.WithTx(tx)
+
+	locked, err := q.LockBattle(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBattleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle: %w", err)
+	}
+	battle := game_db.GetBattleRow(locked)
+
+	if battle.Status.String != "active" {
+		return nil, fmt.Errorf("battle is not active")
+	}
+
+	state, err := loadState(ctx, q, battle)
+	if err != nil {
+		return nil, err
+	}
+	side, opponent, err := state.Sides(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := finishBattle(ctx, q, battle.ID, battle.CurrentTurn.Int32, opponent.PlayerID, side.PlayerID, reason); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Info().
+		Str("battle_id", battle.ID.String()).
+		Str("player_id", playerID.String()).
+		Str("winner_id", opponent.PlayerID.String()).
+		Str("reason", reason).
+		Msg("Player forfeited the battle")
+
+	result := stateResult(battle.ID, state)
+	result.TurnResolved = true
+	result.Message = message
+	result.BattleEnded = true
+	result.WinnerID = opponent.PlayerID
+	result.EndReason = reason
+	return result, nil
+}
+
+// finishBattle marks the battle as completed during turn and records its result,
+// updating the ratings of both players if the battle was ranked
+func finishBattle(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, turn int32, winnerID, loserID pgtype.UUID, reason string) error {
+	ranked, err := q.EndBattle(ctx, game_db.EndBattleParams{
+		ID:       battleID,
+		WinnerID: winnerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to end battle: %w", err)
+	}
+
+	err = q.InsertBattleResult(ctx, game_db.InsertBattleResultParams{
+		BattleID:  battleID,
+		WinnerID:  winnerID,
+		LoserID:   loserID,
+		EndReason: pgtype.Text{String: reason, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save battle result: %w", err)
+	}
+
+	if err := logEnd(ctx, q, battleID, turn, winnerID, reason); err != nil {
+		return err
+	}
+
+	if ranked {
+		return updateRatings(ctx, q, winnerID, loserID)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package battle_s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Kinds of events only found in the battle log, next to the EVENT_* of a turn
+const (
+	EVENT_TEAM   = "team"   // A pokemon a player started the battle with, logged on turn 0
+	EVENT_ACTION = "action" // The action a player chose, logged when the turn is resolved or a fainted pokemon replaced
+	EVENT_END    = "end"    // The battle is over, PlayerID is the winner
+)
+
+// Message of the end event of a battle won by knocking out the whole opposing team
+const ALL_FAINTED_MESSAGE = "All pokemon of a player fainted! The battle is over."
+
+// ErrBattleNotFound is returned for a battle that does not exist, or no longer does
+var ErrBattleNotFound = errors.New("battle not found")
+
+// ReplayResult is everything recorded about a battle, events in the order they happened
+type ReplayResult struct {
+	Battle game_db.GetBattleReplayRow
+	Events []game_db.BattleEvent
+}
+
+// GetReplay returns the event log of a battle
+func (s *BattleService) GetReplay(ctx context.Context, battleID pgtype.UUID) (*ReplayResult, error) {
+	battle, err := s.DBQueries.GetBattleReplay(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBattleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle: %w", err)
+	}
+
+	events, err := s.DBQueries.GetBattleEvents(ctx, battleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle events: %w", err)
+	}
+	if events == nil {
+		events = []game_db.BattleEvent{}
+	}
+
+	return &ReplayResult{Battle: battle, Events: events}, nil
+}
+
+// logTeams records the teams a battle starts with, player1's team first
+func logTeams(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, sides ...engine.Side) error {
+	for _, side := range sides {
+		for _, poke := range side.Team {
+			err := q.InsertBattleEvent(ctx, game_db.InsertBattleEventParams{
+				BattleID:    battleID,
+				Turn:        0,
+				EventType:   EVENT_TEAM,
+				PlayerID:    side.PlayerID,
+				Position:    pgtype.Int4{Int32: poke.Position, Valid: true},
+				SpeciesID:   pgtype.Int4{Int32: poke.SpeciesID, Valid: true},
+				RemainingHp: pgtype.Int4{Int32: poke.HP, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to log team: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// logTurn records the actions chosen for a resolved turn, or the switch replacing a
+// fainted pokemon, in the order they were handed to the engine, followed by everything they caused
+func logTurn(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, turn int32, actions []engine.Action, events []engine.Event) error {
+	for _, action := range actions {
+		params := game_db.InsertBattleEventParams{
+			BattleID:   battleID,
+			Turn:       turn,
+			EventType:  EVENT_ACTION,
+			PlayerID:   action.PlayerID,
+			ActionType: pgtype.Text{String: action.Type, Valid: true},
+		}
+		if action.Type == engine.ACTION_ATTACK {
+			params.MoveID = pgtype.Int4{Int32: action.MoveID, Valid: true}
+		} else {
+			params.Position = pgtype.Int4{Int32: action.Position, Valid: true}
+		}
+		if err := q.InsertBattleEvent(ctx, params); err != nil {
+			return fmt.Errorf("failed to log action: %w", err)
+		}
+	}
+
+	for _, event := range events {
+		params := game_db.InsertBattleEventParams{
+			BattleID:    battleID,
+			Turn:        turn,
+			EventType:   event.Type,
+			PlayerID:    event.PlayerID,
+			Position:    pgtype.Int4{Int32: event.Position, Valid: true},
+			MoveID:      pgtype.Int4{Int32: event.MoveID, Valid: event.MoveID != 0},
+			Damage:      pgtype.Int4{Int32: event.Damage, Valid: event.Damage != 0},
+			RemainingHp: pgtype.Int4{Int32: event.RemainingHP, Valid: true},
+			CriticalHit: event.CriticalHit,
+			Message:     event.Message,
+		}
+		if event.Effectiveness != "" {
+			params.Effectiveness = pgtype.Text{String: event.Effectiveness, Valid: true}
+		}
+		if event.Status != "" {
+			params.Status = pgtype.Text{String: event.Status, Valid: true}
+		}
+		if event.Stat != "" {
+			params.Stat = pgtype.Text{String: event.Stat, Valid: true}
+			params.StatChange = pgtype.Int4{Int32: event.StatChange, Valid: true}
+		}
+		if err := q.InsertBattleEvent(ctx, params); err != nil {
+			return fmt.Errorf("failed to log event: %w", err)
+		}
+	}
+	return nil
+}
+
+// logEnd records who won the battle and why
+func logEnd(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, turn int32, winnerID pgtype.UUID, reason string) error {
+	message, ok := forfeitMessages[reason]
+	if !ok {
+		message = ALL_FAINTED_MESSAGE
+	}
+
+	err := q.InsertBattleEvent(ctx, game_db.InsertBattleEventParams{
+		BattleID:  battleID,
+		Turn:      turn,
+		EventType: EVENT_END,
+		PlayerID:  winnerID,
+		EndReason: pgtype.Text{String: reason, Valid: true},
+		Message:   message,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log battle end: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package battle_s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// initMovePP loads the full PP of every move of a player's team into the battle
+func initMovePP(ctx context.Context, q *game_db.Queries, battleID, playerID pgtype.UUID) error {
+	err := q.InitBattleMovePP(ctx, game_db.InitBattleMovePPParams{
+		BattleID: battleID,
+		UserID:   playerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init move PP: %w", err)
+	}
+	return nil
+}
+
+// loadMovePP fills in the moves of both teams of a battle with the PP they have left
+func loadMovePP(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, state *engine.BattleState) error {
+	rows, err := q.GetBattleMovePP(ctx, battleID)
+	if err != nil {
+		return fmt.Errorf("failed to get move PP: %w", err)
+	}
+
+	for _, row := range rows {
+		side, _, err := state.Sides(row.UserID)
+		if err != nil {
+			continue
+		}
+		poke := side.Pokemon(row.Position)
+		if poke == nil {
+			continue
+		}
+		poke.Moves = append(poke.Moves, engine.MoveSlot{
+			MoveID: row.MoveID,
+			Name:   row.Name,
+			PP:     row.RemainingPp,
+			MaxPP:  row.MaxPp,
+		})
+	}
+	return nil
+}
+
+// saveMovePP stores the PP a pokemon used since it was loaded
+func saveMovePP(ctx context.Context, q *game_db.Queries, battleID, playerID pgtype.UUID, before, after engine.Pokemon) error {
+	for _, move := range after.Moves {
+		old := before.Move(move.MoveID)
+		if old == nil {
+			continue
+		}
+		for spent := old.PP - move.PP; spent > 0; spent-- {
+			_, err := q.UseMovePP(ctx, game_db.UseMovePPParams{
+				BattleID: battleID,
+				UserID:   playerID,
+				Position: after.Position,
+				MoveID:   move.MoveID,
+			})
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("no PP left for move %d", move.MoveID)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to use move PP: %w", err)
+			}
+		}
+	}
+	return nil
+}
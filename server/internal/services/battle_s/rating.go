@@ -0,0 +1,78 @@
+package battle_s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// Rating of a player that never finished a ranked battle
+const INITIAL_RATING = 1500
+
+// Most rating points a single ranked battle can move
+const RATING_K_FACTOR = 32
+
+// GetRating returns the current rating of a player
+func (s *BattleService) GetRating(ctx context.Context, playerID pgtype.UUID) (int32, error) {
+	_, rating, err := getPlayerRating(ctx, s.DBQueries, playerID)
+	return rating, err
+}
+
+// getPlayerRating returns the username of a player, which their rating is kept by, and their rating.
+// Usernames are not unique, the ws handler only lets a player queue for ranked while no one
+// else connected uses their name.
+func getPlayerRating(ctx context.Context, q *game_db.Queries, playerID pgtype.UUID) (string, int32, error) {
+	row, err := q.GetPlayerRating(ctx, playerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", 0, fmt.Errorf("player not found")
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get rating: %w", err)
+	}
+	if !row.Rating.Valid {
+		return row.Username, INITIAL_RATING, nil
+	}
+	return row.Username, row.Rating.Int32, nil
+}
+
+// updateRatings moves rating points from the loser of a ranked battle to its winner
+func updateRatings(ctx context.Context, q *game_db.Queries, winnerID, loserID pgtype.UUID) error {
+	winnerName, winnerRating, err := getPlayerRating(ctx, q, winnerID)
+	if err != nil {
+		return err
+	}
+	loserName, loserRating, err := getPlayerRating(ctx, q, loserID)
+	if err != nil {
+		return err
+	}
+
+	change := eloChange(winnerRating, loserRating)
+	for _, rating := range []game_db.SavePlayerRatingParams{
+		{Username: winnerName, Rating: winnerRating + change},
+		{Username: loserName, Rating: loserRating - change},
+	} {
+		if err := q.SavePlayerRating(ctx, rating); err != nil {
+			return fmt.Errorf("failed to save rating: %w", err)
+		}
+	}
+
+	log.Info().
+		Str("winner", winnerName).
+		Str("loser", loserName).
+		Int32("change", change).
+		Msg("Ratings updated")
+	return nil
+}
+
+// eloChange returns the points the winner takes from the loser. Beating a
+// stronger player is worth more than beating a weaker one.
+func eloChange(winnerRating, loserRating int32) int32 {
+	expected := 1 / (1 + math.Pow(10, float64(loserRating-winnerRating)/400))
+	return int32(math.Round(RATING_K_FACTOR * (1 - expected)))
+}
@@ -0,0 +1,38 @@
+package battle_s
+
+import "testing"
+
+func TestEloChange(t *testing.T) {
+	tests := []struct {
+		name          string
+		winner, loser int32
+		want          int32
+	}{
+		{"equal ratings", INITIAL_RATING, INITIAL_RATING, RATING_K_FACTOR / 2},
+		{"upset", 1500, 1700, 24},
+		{"big upset", 1500, 1900, 29},
+		{"favourite wins", 1900, 1500, 3},
+		{"heavy favourite wins", 2400, 1000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eloChange(tt.winner, tt.loser); got != tt.want {
+				t.Fatalf("%d beating %d: expected %d points, got %d", tt.winner, tt.loser, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEloChangeBounds(t *testing.T) {
+	for _, diff := range []int32{-800, -400, -100, 0, 100, 400, 800} {
+		win := eloChange(INITIAL_RATING+diff, INITIAL_RATING)
+		upset := eloChange(INITIAL_RATING, INITIAL_RATING+diff)
+		if win < 0 || win > RATING_K_FACTOR {
+			t.Fatalf("a battle moved %d points, at most %d allowed", win, RATING_K_FACTOR)
+		}
+		// Whatever one side would win the other would have lost, rounding aside
+		if sum := win + upset; sum < RATING_K_FACTOR-1 || sum > RATING_K_FACTOR+1 {
+			t.Fatalf("rating difference %d: %d and %d should add up to %d", diff, win, upset, RATING_K_FACTOR)
+		}
+	}
+}
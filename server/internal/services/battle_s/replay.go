@@ -0,0 +1,221 @@
+package battle_s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ReplayMismatch is a recorded event that the engine did not reproduce
+type ReplayMismatch struct {
+	Turn     int32
+	Sequence int32 // 0 when the engine produced an event that was never recorded
+	Recorded string
+	Replayed string
+}
+
+// ReplayReport is the outcome of re-running a battle from its event log
+type ReplayReport struct {
+	BattleID   pgtype.UUID
+	Turns      int32
+	Events     int
+	Mismatches []ReplayMismatch
+}
+
+// VerifyBattle replays a stored battle and reports where it differs from its event log
+func (s *BattleService) VerifyBattle(ctx context.Context, battleID pgtype.UUID) (*ReplayReport, error) {
+	rules, err := s.battleEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := s.GetReplay(ctx, battleID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := ReplayEvents(rules, replay.Battle.RngSeed, replay.Events)
+	if err != nil {
+		return nil, err
+	}
+	report.BattleID = battleID
+	return report, nil
+}
+
+// ReplayEvents re-runs a battle from the teams and actions of its event log, without
+// the database, and compares what the engine produces with what was recorded
+func ReplayEvents(rules *engine.Engine, seed int64, events []game_db.BattleEvent) (*ReplayReport, error) {
+	state, err := replayState(rules.Catalog, seed, events)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{Events: len(events), Mismatches: []ReplayMismatch{}}
+
+	for _, logged := range groupByTurn(events) {
+		turn, turnEvents := logged.Turn, logged.Events
+		if turn == 0 {
+			continue
+		}
+		report.Turns = turn
+		state.Turn = turn
+		state.Pending = nil
+
+		actions := []engine.Action{}
+		recorded := []game_db.BattleEvent{}
+		var end *game_db.BattleEvent
+		for i, event := range turnEvents {
+			switch event.EventType {
+			case EVENT_ACTION:
+				actions = append(actions, engine.Action{
+					PlayerID: event.PlayerID,
+					Type:     event.ActionType.String,
+					MoveID:   event.MoveID.Int32,
+					Position: event.Position.Int32,
+				})
+			case EVENT_END:
+				end = &turnEvents[i]
+			default:
+				recorded = append(recorded, event)
+			}
+		}
+
+		// Switches replacing fainted pokemon come first, and are the only actions
+		// of a turn when the battle was forfeited before it was resolved
+		if len(actions) > 0 {
+			replayed := []engine.Event{}
+			for _, action := range actions {
+				next, actionEvents, err := rules.Apply(state, action)
+				if err != nil {
+					return nil, fmt.Errorf("failed to replay turn %d: %w", turn, err)
+				}
+				state = next
+				replayed = append(replayed, actionEvents...)
+			}
+			report.Mismatches = append(report.Mismatches, compareEvents(turn, recorded, replayed)...)
+		}
+
+		winnerID, ended := state.Winner()
+		recordedAllFainted := end != nil && end.EndReason.String == END_REASON_ALL_FAINTED
+		if ended != recordedAllFainted || (ended && end.PlayerID != winnerID) {
+			report.Mismatches = append(report.Mismatches, ReplayMismatch{
+				Turn:     turn,
+				Recorded: describeEnd(end),
+				Replayed: describeReplayedEnd(ended, winnerID),
+			})
+		}
+		if end != nil {
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// replayState builds the state a battle started in from the team events of its log
+func replayState(catalog *engine.Catalog, seed int64, events []game_db.BattleEvent) (engine.BattleState, error) {
+	state := engine.BattleState{Seed: seed}
+
+	sides := []*engine.Side{}
+	for _, event := range events {
+		if event.EventType != EVENT_TEAM {
+			continue
+		}
+		var side *engine.Side
+		for _, known := range sides {
+			if known.PlayerID == event.PlayerID {
+				side = known
+			}
+		}
+		if side == nil {
+			side = &engine.Side{PlayerID: event.PlayerID, ActivePos: 1}
+			sides = append(sides, side)
+		}
+
+		poke, err := catalog.NewPokemon(event.Position.Int32, event.SpeciesID.Int32)
+		if err != nil {
+			return state, err
+		}
+		poke.HP = event.RemainingHp.Int32
+		side.Team = append(side.Team, poke)
+	}
+	if len(sides) != 2 {
+		return state, fmt.Errorf("the log has teams for %d players instead of 2", len(sides))
+	}
+
+	state.Player1, state.Player2 = *sides[0], *sides[1]
+	return state, nil
+}
+
+// loggedTurn holds the events of a single turn of the log
+type loggedTurn struct {
+	Turn   int32
+	Events []game_db.BattleEvent
+}
+
+// groupByTurn splits an ordered event log by turn, keeping the order
+func groupByTurn(events []game_db.BattleEvent) []loggedTurn {
+	turns := []loggedTurn{}
+	for _, event := range events {
+		if len(turns) == 0 || turns[len(turns)-1].Turn != event.Turn {
+			turns = append(turns, loggedTurn{Turn: event.Turn})
+		}
+		turns[len(turns)-1].Events = append(turns[len(turns)-1].Events, event)
+	}
+	return turns
+}
+
+// compareEvents pairs the recorded events of a turn with the replayed ones, in order
+func compareEvents(turn int32, recorded []game_db.BattleEvent, replayed []engine.Event) []ReplayMismatch {
+	mismatches := []ReplayMismatch{}
+	for i := 0; i < max(len(recorded), len(replayed)); i++ {
+		mismatch := ReplayMismatch{Turn: turn, Recorded: "nothing", Replayed: "nothing"}
+		if i < len(recorded) {
+			mismatch.Sequence = recorded[i].Sequence
+			mismatch.Recorded = describeRecorded(recorded[i])
+		}
+		if i < len(replayed) {
+			mismatch.Replayed = describeReplayed(replayed[i])
+		}
+		if mismatch.Recorded != mismatch.Replayed {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	return mismatches
+}
+
+// describeRecorded and describeReplayed write an event the same way whatever its source,
+// so two events match when their descriptions do
+func describeRecorded(event game_db.BattleEvent) string {
+	return describeEvent(event.EventType, event.PlayerID, event.Position.Int32, event.MoveID.Int32,
+		event.Damage.Int32, event.RemainingHp.Int32, event.Effectiveness.String, event.CriticalHit, event.Status.String,
+		event.Stat.String, event.StatChange.Int32)
+}
+
+func describeReplayed(event engine.Event) string {
+	return describeEvent(event.Type, event.PlayerID, event.Position, event.MoveID,
+		event.Damage, event.RemainingHP, event.Effectiveness, event.CriticalHit, event.Status,
+		event.Stat, event.StatChange)
+}
+
+func describeEvent(eventType string, playerID pgtype.UUID, position, moveID, damage, remainingHP int32, effectiveness string, criticalHit bool, status, stat string, statChange int32) string {
+	return fmt.Sprintf("%s player=%s position=%d move=%d damage=%d hp=%d effectiveness=%s critical=%t status=%s stat=%s%+d",
+		eventType, playerID.String(), position, moveID, damage, remainingHP, effectiveness, criticalHit, status, stat, statChange)
+}
+
+func describeEnd(end *game_db.BattleEvent) string {
+	if end == nil {
+		return "battle goes on"
+	}
+	return fmt.Sprintf("end reason=%s winner=%s", end.EndReason.String, end.PlayerID.String())
+}
+
+func describeReplayedEnd(ended bool, winnerID pgtype.UUID) string {
+	if !ended {
+		return "battle goes on"
+	}
+	return fmt.Sprintf("end reason=%s winner=%s", END_REASON_ALL_FAINTED, winnerID.String())
+}
@@ -0,0 +1,140 @@
+package battle_s
+
+import (
+	"testing"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	player1 = pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	player2 = pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+)
+
+// highRoller always rolls the highest outcome: every move hits for full damage, never critically
+type highRoller struct{}
+
+func (highRoller) IntN(n int) int { return n - 1 }
+
+// replayRules returns an engine over two species that only know Tackle
+func replayRules() *engine.Engine {
+	return &engine.Engine{
+		Catalog: &engine.Catalog{
+			Species: map[int32]engine.Species{
+				1: {ID: 1, Name: "Swift", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 90, Type1: "normal"},
+				2: {ID: 2, Name: "Sluggish", BaseHP: 100, BaseAttack: 50, BaseDefense: 50, BaseSpeed: 30, Type1: "normal"},
+			},
+			Moves: map[int32]engine.Move{
+				1: {ID: 1, Name: "Tackle", Type: "normal", Power: 40, Accuracy: 100, PP: 35},
+			},
+			SpeciesMoves: map[int32][]int32{1: {1}, 2: {1}},
+		},
+		NewRoller: func(int64, int32) engine.Roller { return highRoller{} },
+	}
+}
+
+func int4(value int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: value, Valid: true}
+}
+
+func text(value string) pgtype.Text {
+	return pgtype.Text{String: value, Valid: true}
+}
+
+// replayLog is the log of a battle where Swift knocks out a weakened Sluggish on turn 1,
+// and its player sends out another Sluggish on turn 2
+func replayLog() []game_db.BattleEvent {
+	events := []game_db.BattleEvent{
+		{Turn: 0, EventType: EVENT_TEAM, PlayerID: player1, Position: int4(1), SpeciesID: int4(1), RemainingHp: int4(100)},
+		{Turn: 0, EventType: EVENT_TEAM, PlayerID: player2, Position: int4(1), SpeciesID: int4(2), RemainingHp: int4(10)},
+		{Turn: 0, EventType: EVENT_TEAM, PlayerID: player2, Position: int4(2), SpeciesID: int4(2), RemainingHp: int4(100)},
+		{Turn: 1, EventType: EVENT_ACTION, PlayerID: player1, ActionType: text(engine.ACTION_ATTACK), MoveID: int4(1)},
+		{Turn: 1, EventType: EVENT_ACTION, PlayerID: player2, ActionType: text(engine.ACTION_ATTACK), MoveID: int4(1)},
+		{Turn: 1, EventType: engine.EVENT_ATTACK, PlayerID: player1, Position: int4(1), MoveID: int4(1), Damage: int4(28), RemainingHp: int4(0), Effectiveness: text(engine.EFFECTIVENESS_NORMAL)},
+		{Turn: 1, EventType: engine.EVENT_FAINT, PlayerID: player2, Position: int4(1), RemainingHp: int4(0)},
+		{Turn: 2, EventType: EVENT_ACTION, PlayerID: player2, ActionType: text(engine.ACTION_SWITCH), Position: int4(2)},
+		{Turn: 2, EventType: engine.EVENT_SWITCH, PlayerID: player2, Position: int4(2), RemainingHp: int4(100)},
+	}
+	for i := range events {
+		events[i].Sequence = int32(i + 1)
+	}
+	return events
+}
+
+func TestReplayEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(events []game_db.BattleEvent) []game_db.BattleEvent
+		mismatches []ReplayMismatch // Only Turn and Sequence are compared
+	}{
+		{
+			name:   "faithful log",
+			tamper: func(events []game_db.BattleEvent) []game_db.BattleEvent { return events },
+		},
+		{
+			name: "altered damage",
+			tamper: func(events []game_db.BattleEvent) []game_db.BattleEvent {
+				events[5].Damage = int4(30)
+				return events
+			},
+			mismatches: []ReplayMismatch{{Turn: 1, Sequence: 6}},
+		},
+		{
+			name: "missing event",
+			tamper: func(events []game_db.BattleEvent) []game_db.BattleEvent {
+				return append(events[:6], events[7:]...)
+			},
+			mismatches: []ReplayMismatch{{Turn: 1, Sequence: 0}},
+		},
+		{
+			name: "end the engine did not reach",
+			tamper: func(events []game_db.BattleEvent) []game_db.BattleEvent {
+				return append(events[:7], game_db.BattleEvent{
+					Sequence: 8, Turn: 1, EventType: EVENT_END, PlayerID: player1, EndReason: text(END_REASON_ALL_FAINTED),
+				})
+			},
+			mismatches: []ReplayMismatch{{Turn: 1, Sequence: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := tt.tamper(replayLog())
+			report, err := ReplayEvents(replayRules(), 1, events)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Events != len(events) {
+				t.Fatalf("expected %d events, got %d", len(events), report.Events)
+			}
+			if len(report.Mismatches) != len(tt.mismatches) {
+				t.Fatalf("expected %d mismatches, got %+v", len(tt.mismatches), report.Mismatches)
+			}
+			for i, want := range tt.mismatches {
+				got := report.Mismatches[i]
+				if got.Turn != want.Turn || got.Sequence != want.Sequence {
+					t.Fatalf("expected a mismatch on turn %d sequence %d, got %+v", want.Turn, want.Sequence, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReplayEventsNeedsBothTeams(t *testing.T) {
+	events := replayLog()
+	events = append(events[:1], events[3:]...)
+
+	if _, err := ReplayEvents(replayRules(), 1, events); err == nil {
+		t.Fatal("a log with a single team can't be replayed")
+	}
+}
+
+func TestReplayEventsInvalidAction(t *testing.T) {
+	events := replayLog()
+	events[7].Position = int4(1)
+
+	if _, err := ReplayEvents(replayRules(), 1, events); err == nil {
+		t.Fatal("switching to a fainted pokemon can't be replayed")
+	}
+}
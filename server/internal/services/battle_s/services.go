@@ -2,61 +2,198 @@ package battle_s
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/DanielRasho/PokeSocket/internal/engine"
 	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog/log"
 )
 
+// BattleService stores battles and their state, the rules themselves live in the engine package
 type BattleService struct {
 	DBClient  *pgxpool.Pool
 	DBQueries *game_db.Queries
+	TypeChart engine.TypeChart     // Falls back to engine.DefaultTypeChart when nil
+	NewRoller engine.RollerFactory // Falls back to engine.SeededRoller when nil
+
+	catalogMu sync.Mutex
+	catalog   *engine.Catalog // Game data, read on first use
 }
 
 func New(usersDBClient *pgxpool.Pool, usersQueries *game_db.Queries) *BattleService {
 	return &BattleService{
 		DBClient:  usersDBClient,
 		DBQueries: usersQueries,
+		TypeChart: engine.DefaultTypeChart,
+		NewRoller: engine.SeededRoller,
+	}
+}
+
+// battleEngine returns the engine battles are played with, reading the game data the
+// first time it is needed. Species and moves never change while the server runs.
+func (s *BattleService) battleEngine(ctx context.Context) (*engine.Engine, error) {
+	s.catalogMu.Lock()
+	defer s.catalogMu.Unlock()
+
+	if s.catalog == nil {
+		catalog, err := s.LoadCatalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.catalog = catalog
+	}
+	return &engine.Engine{
+		Catalog:   s.catalog,
+		TypeChart: s.TypeChart,
+		NewRoller: s.NewRoller,
+	}, nil
+}
+
+// LoadCatalog reads every species and move, with its effects, from the database
+func (s *BattleService) LoadCatalog(ctx context.Context) (*engine.Catalog, error) {
+	species, err := s.DBQueries.ListPokemonSpecies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pokemon species: %w", err)
+	}
+	moves, err := s.DBQueries.ListMoves(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list moves: %w", err)
+	}
+	speciesMoves, err := s.DBQueries.ListSpeciesMoves(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list species moves: %w", err)
+	}
+	effects, err := s.DBQueries.ListMoveEffects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list move effects: %w", err)
+	}
+
+	catalog := &engine.Catalog{
+		Species:      make(map[int32]engine.Species, len(species)),
+		Moves:        make(map[int32]engine.Move, len(moves)),
+		SpeciesMoves: map[int32][]int32{},
+	}
+	for _, row := range species {
+		catalog.Species[row.ID] = engine.Species{
+			ID:          row.ID,
+			Name:        row.Name,
+			BaseHP:      row.BaseHp,
+			BaseAttack:  row.BaseAttack,
+			BaseDefense: row.BaseDefense,
+			BaseSpeed:   row.BaseSpeed,
+			Type1:       row.Type1,
+			Type2:       row.Type2.String,
+		}
+	}
+	for _, row := range moves {
+		catalog.Moves[row.ID] = engine.Move{
+			ID:       row.ID,
+			Name:     row.Name,
+			Type:     row.Type,
+			Power:    row.Power,
+			Accuracy: row.Accuracy,
+			PP:       row.Pp,
+			Priority: row.Priority,
+		}
+	}
+	for _, row := range effects {
+		move, ok := catalog.Moves[row.MoveID]
+		if !ok {
+			continue
+		}
+		effect := engine.Effect{
+			Kind:      row.Kind,
+			Target:    row.Target,
+			Chance:    row.Chance,
+			Magnitude: row.Magnitude,
+			Status:    row.Status.String,
+			Stat:      row.Stat.String,
+		}
+		if err := effect.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid effect %d of move %s: %w", row.ID, move.Name, err)
+		}
+		move.Effects = append(move.Effects, effect)
+		catalog.Moves[row.MoveID] = move
 	}
+	for _, row := range speciesMoves {
+		catalog.SpeciesMoves[row.PokemonSpeciesID] = append(catalog.SpeciesMoves[row.PokemonSpeciesID], row.MoveID)
+	}
+	return catalog, nil
 }
 
 // BattleInfo contains all information about a created battle
 type BattleInfo struct {
 	BattleID    pgtype.UUID
 	Player1ID   pgtype.UUID
-	Player1Team []game_db.UserTeam
+	Player1Team []engine.Pokemon
 	Player2ID   pgtype.UUID
-	Player2Team []game_db.UserTeam
+	Player2Team []engine.Pokemon
+	Ranked      bool
 }
 
-// CreateBattle creates a new battle between two players
-func (s *BattleService) CreateBattle(ctx context.Context, player1ID, player2ID pgtype.UUID) (*BattleInfo, error) {
+// CreateBattle creates a new battle between two players, snapshotting both teams
+// so the battle never modifies the registered teams. Ranked battles update the
+// ratings of both players once they end.
+func (s *BattleService) CreateBattle(ctx context.Context, player1ID, player2ID pgtype.UUID, ranked bool) (*BattleInfo, error) {
 	// Generate battle ID
 	battleID := pgtype.UUID{Bytes: uuid.New(), Valid: true}
 
+	tx, err := s.DBClient.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.DBQueries.WithTx(tx)
+
 	// Create battle entry
-	battle, err := s.DBQueries.CreateBattle(ctx, game_db.CreateBattleParams{
+	battle, err := q.CreateBattle(ctx, game_db.CreateBattleParams{
 		ID:        battleID,
 		Player1ID: player1ID,
 		Player2ID: player2ID,
+		RngSeed:   engine.NewBattleSeed(),
+		Ranked:    ranked,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create battle: %w", err)
 	}
 
-	// Get player 1 team
-	player1Team, err := s.DBQueries.GetUserTeam(ctx, player1ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get player1 team: %w", err)
+	// Copy both teams at full health, every move gets its full PP
+	for _, playerID := range []pgtype.UUID{player1ID, player2ID} {
+		err := q.SnapshotBattleTeam(ctx, game_db.SnapshotBattleTeamParams{
+			BattleID: battle.ID,
+			UserID:   playerID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot team: %w", err)
+		}
+		if err := initMovePP(ctx, q, battle.ID, playerID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Get player 2 team
-	player2Team, err := s.DBQueries.GetUserTeam(ctx, player2ID)
+	created, err := q.GetBattle(ctx, battle.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get player2 team: %w", err)
+		return nil, fmt.Errorf("failed to get battle: %w", err)
+	}
+	state, err := loadState(ctx, q, created)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := logTeams(ctx, q, battle.ID, state.Player1, state.Player2); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	log.Info().
@@ -67,357 +204,132 @@ func (s *BattleService) CreateBattle(ctx context.Context, player1ID, player2ID p
 
 	return &BattleInfo{
 		BattleID:    battle.ID,
-		Player1ID:   player1ID,
-		Player1Team: player1Team,
-		Player2ID:   player2ID,
-		Player2Team: player2Team,
+		Player1ID:   battle.Player1ID,
+		Player1Team: state.Player1.Team,
+		Player2ID:   battle.Player2ID,
+		Player2Team: state.Player2.Team,
+		Ranked:      battle.Ranked,
 	}, nil
 }
 
-// DeleteBattle removes a battle from the database
-func (s *BattleService) DeleteBattle(ctx context.Context, battleID pgtype.UUID) error {
-	err := s.DBQueries.DeleteBattle(ctx, battleID)
+// GetBattleState returns the current state of a battle
+func (s *BattleService) GetBattleState(ctx context.Context, battleID pgtype.UUID) (*BattleStateResult, error) {
+	battle, err := s.DBQueries.GetBattle(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBattleNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete battle: %w", err)
+		return nil, fmt.Errorf("failed to get battle: %w", err)
 	}
 
-	log.Info().
-		Str("battle_id", battleID.String()).
-		Msg("Battle deleted successfully")
-
-	return nil
-}
+	state, err := loadState(ctx, s.DBQueries, battle)
+	if err != nil {
+		return nil, err
+	}
 
-// AttackRequest contains all data needed for an attack
-type AttackRequest struct {
-	BattleID   pgtype.UUID
-	AttackerID pgtype.UUID
-	DefenderID pgtype.UUID
-	MoveID     int
+	result := stateResult(battle.ID, state)
+	result.Message = fmt.Sprintf("Turn %d", battle.CurrentTurn.Int32)
+	return result, nil
 }
 
 // BattleStateResult contains the complete battle state after an action
 type BattleStateResult struct {
 	BattleID         pgtype.UUID
+	Turn             int32 // Turn the submitted action belongs to
+	TurnResolved     bool  // False while waiting for the opponent's action
 	Message          string
+	Events           []engine.Event // Ordered events of the resolved turn
 	Player1ID        pgtype.UUID
-	Player1Team      []game_db.UserTeam
+	Player1Team      []engine.Pokemon
 	Player1ActivePos int32
 	Player2ID        pgtype.UUID
-	Player2Team      []game_db.UserTeam
+	Player2Team      []engine.Pokemon
 	Player2ActivePos int32
+	Player1Switching bool // Player1 has to replace its fainted active pokemon before the turn goes on
+	Player2Switching bool // Same for Player2
+	ForcedSwitch     bool // The action replaced a fainted pokemon, Turn is still waiting for both actions
 	BattleEnded      bool
 	WinnerID         pgtype.UUID
+	EndReason        string // Why the battle ended, see END_REASON_*
 }
 
-// AttackPokemon processes a pokemon attack and returns the new battle state
-func (s *BattleService) AttackPokemon(ctx context.Context, req AttackRequest) (*BattleStateResult, error) {
-	// Get battle info
-	battle, err := s.DBQueries.GetBattle(ctx, req.BattleID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get battle: %w", err)
-	}
-
-	// Validate turn: odd turns = player1, even turns = player2
-	currentTurn := battle.CurrentTurn.Int32
-	isPlayer1Turn := currentTurn%2 == 1
-	isPlayer1Attacking := req.AttackerID.Bytes == battle.Player1ID.Bytes
-
-	if isPlayer1Turn && !isPlayer1Attacking {
-		return nil, fmt.Errorf("not your turn - it's player1's turn")
-	}
-	if !isPlayer1Turn && isPlayer1Attacking {
-		return nil, fmt.Errorf("not your turn - it's player2's turn")
-	}
-
-	// Determine defender's active pokemon position based on who is attacking
-	var defenderPos int32
-	if req.AttackerID.Bytes == battle.Player1ID.Bytes {
-		defenderPos = battle.Player2ActivePokemonPosition.Int32
-	} else {
-		defenderPos = battle.Player1ActivePokemonPosition.Int32
-	}
-
-	// Get move information (for now, using a simple damage calculation)
-	// TODO: Get actual move data from pokemon_moves table
-	baseDamage := 10 // Placeholder damage
-
-	// Calculate damage (simplified formula)
-	damage := int32(baseDamage)
-
-	// Get defender's current pokemon HP
-	defenderTeam, err := s.DBQueries.GetUserTeam(ctx, req.DefenderID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get defender team: %w", err)
+// stateResult returns the battle state as seen by clients
+func stateResult(battleID pgtype.UUID, state engine.BattleState) *BattleStateResult {
+	return &BattleStateResult{
+		BattleID:         battleID,
+		Turn:             state.Turn,
+		Events:           []engine.Event{},
+		Player1ID:        state.Player1.PlayerID,
+		Player1Team:      state.Player1.Team,
+		Player1ActivePos: state.Player1.ActivePos,
+		Player2ID:        state.Player2.PlayerID,
+		Player2Team:      state.Player2.Team,
+		Player2ActivePos: state.Player2.ActivePos,
+		Player1Switching: state.MustSwitch(state.Player1.PlayerID),
+		Player2Switching: state.MustSwitch(state.Player2.PlayerID),
 	}
+}
 
-	// Find the defending pokemon
-	var defenderPokemon game_db.UserTeam
-	for _, poke := range defenderTeam {
-		if poke.Position == defenderPos {
-			defenderPokemon = poke
-			break
+// loadState reads the teams, PP and pending actions of a battle into the state the engine plays with
+func loadState(ctx context.Context, q *game_db.Queries, battle game_db.GetBattleRow) (engine.BattleState, error) {
+	state := engine.BattleState{
+		Seed: battle.RngSeed,
+		Turn: battle.CurrentTurn.Int32,
+		Player1: engine.Side{
+			PlayerID:  battle.Player1ID,
+			ActivePos: battle.Player1ActivePokemonPosition.Int32,
+		},
+		Player2: engine.Side{
+			PlayerID:  battle.Player2ID,
+			ActivePos: battle.Player2ActivePokemonPosition.Int32,
+		},
+	}
+
+	for _, side := range []*engine.Side{&state.Player1, &state.Player2} {
+		team, err := q.GetBattleTeam(ctx, game_db.GetBattleTeamParams{
+			BattleID: battle.ID,
+			UserID:   side.PlayerID,
+		})
+		if err != nil {
+			return state, fmt.Errorf("failed to get team: %w", err)
 		}
-	}
-
-	// Calculate new HP
-	newHP := defenderPokemon.CurrentHp - damage
-	if newHP < 0 {
-		newHP = 0
-	}
-
-	// Update defender's pokemon HP
-	err = s.DBQueries.UpdatePokemonHP(ctx, game_db.UpdatePokemonHPParams{
-		UserID:    req.DefenderID,
-		Position:  defenderPos,
-		CurrentHp: newHP,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to update pokemon HP: %w", err)
-	}
-
-	// If pokemon fainted, auto-switch to next available pokemon
-	if newHP == 0 {
-		nextPokemon := s.findNextAvailablePokemon(defenderTeam, defenderPos)
-		if nextPokemon != nil {
-			// Update the battle's active pokemon position for the defender
-			if req.DefenderID.Bytes == battle.Player1ID.Bytes {
-				err = s.DBQueries.UpdatePlayer1ActivePokemon(ctx, game_db.UpdatePlayer1ActivePokemonParams{
-					ID:                           battle.ID,
-					Player1ActivePokemonPosition: pgtype.Int4{Int32: nextPokemon.Position, Valid: true},
-				})
-			} else {
-				err = s.DBQueries.UpdatePlayer2ActivePokemon(ctx, game_db.UpdatePlayer2ActivePokemonParams{
-					ID:                           battle.ID,
-					Player2ActivePokemonPosition: pgtype.Int4{Int32: nextPokemon.Position, Valid: true},
-				})
+		side.Team = make([]engine.Pokemon, len(team))
+		for i, poke := range team {
+			side.Team[i] = engine.Pokemon{
+				Position:    poke.Position,
+				SpeciesID:   poke.PokemonSpeciesID.Int32,
+				HP:          poke.CurrentHp,
+				Fainted:     poke.IsFainted.Bool,
+				Status:      poke.Status,
+				StatusTurns: poke.StatusTurns,
+				Recharging:  poke.Recharging,
+				Moves:       []engine.MoveSlot{},
 			}
-			if err != nil {
-				log.Warn().Err(err).Msg("Failed to update active pokemon position")
+			if err := json.Unmarshal(poke.Stages, &side.Team[i].Stages); err != nil {
+				return state, fmt.Errorf("failed to read stat stages: %w", err)
 			}
 		}
 	}
 
-	// Update battle turn
-	err = s.DBQueries.UpdateBattleTurn(ctx, req.BattleID)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to update battle turn")
+	if err := loadMovePP(ctx, q, battle.ID, &state); err != nil {
+		return state, err
 	}
 
-	// Re-read battle to get fresh active positions after potential auto-switch
-	updatedBattle, err := s.DBQueries.GetBattle(ctx, req.BattleID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to re-read battle: %w", err)
-	}
-
-	// Get updated teams
-	player1Team, err := s.DBQueries.GetUserTeam(ctx, battle.Player1ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get player1 team: %w", err)
-	}
-
-	player2Team, err := s.DBQueries.GetUserTeam(ctx, battle.Player2ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get player2 team: %w", err)
-	}
-
-	// Create message describing the action
-	isFainted := newHP == 0
-	message := fmt.Sprintf("Attack dealt %d damage! Defender's HP: %d", damage, newHP)
-	if isFainted {
-		message += " - Pokemon fainted!"
-	}
-
-	// Check if battle is over (all pokemon fainted)
-	battleEnded, winnerID := s.checkBattleEnd(player1Team, player2Team, battle.Player1ID, battle.Player2ID)
-
-	log.Info().
-		Str("battle_id", req.BattleID.String()).
-		Str("attacker_id", req.AttackerID.String()).
-		Int32("damage", damage).
-		Int32("new_hp", newHP).
-		Bool("fainted", isFainted).
-		Bool("battle_ended", battleEnded).
-		Msg("Attack processed")
-
-	return &BattleStateResult{
-		BattleID:         req.BattleID,
-		Message:          message,
-		Player1ID:        battle.Player1ID,
-		Player1Team:      player1Team,
-		Player1ActivePos: updatedBattle.Player1ActivePokemonPosition.Int32,
-		Player2ID:        battle.Player2ID,
-		Player2Team:      player2Team,
-		Player2ActivePos: updatedBattle.Player2ActivePokemonPosition.Int32,
-		BattleEnded:      battleEnded,
-		WinnerID:         winnerID,
-	}, nil
-}
-
-// checkBattleEnd checks if all pokemon of one team are fainted
-func (s *BattleService) checkBattleEnd(player1Team, player2Team []game_db.UserTeam, player1ID, player2ID pgtype.UUID) (bool, pgtype.UUID) {
-	player1AllFainted := true
-	player2AllFainted := true
-
-	for _, poke := range player1Team {
-		if !poke.IsFainted.Bool {
-			player1AllFainted = false
-			break
-		}
-	}
-
-	for _, poke := range player2Team {
-		if !poke.IsFainted.Bool {
-			player2AllFainted = false
-			break
-		}
-	}
-
-	if player1AllFainted {
-		return true, player2ID
-	}
-	if player2AllFainted {
-		return true, player1ID
-	}
-
-	return false, pgtype.UUID{}
-}
-
-// findNextAvailablePokemon finds the next pokemon with HP > 0 after the current position
-func (s *BattleService) findNextAvailablePokemon(team []game_db.UserTeam, currentPos int32) *game_db.UserTeam {
-	for _, poke := range team {
-		if poke.Position != currentPos && poke.CurrentHp > 0 && !poke.IsFainted.Bool {
-			return &poke
-		}
-	}
-	return nil
-}
-
-// SwitchPokemonRequest contains all data needed for switching pokemon
-type SwitchPokemonRequest struct {
-	BattleID   pgtype.UUID
-	PlayerID   pgtype.UUID
-	OpponentID pgtype.UUID
-	NewPosition int32
-}
-
-// SwitchPokemon handles switching the active pokemon for a player
-func (s *BattleService) SwitchPokemon(ctx context.Context, req SwitchPokemonRequest) (*BattleStateResult, error) {
-	// Get battle info
-	battle, err := s.DBQueries.GetBattle(ctx, req.BattleID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get battle: %w", err)
-	}
-
-	// Validate turn: odd turns = player1, even turns = player2
-	currentTurn := battle.CurrentTurn.Int32
-	isPlayer1Turn := currentTurn%2 == 1
-	isPlayer1Switching := req.PlayerID.Bytes == battle.Player1ID.Bytes
-
-	if isPlayer1Turn && !isPlayer1Switching {
-		return nil, fmt.Errorf("not your turn - it's player1's turn")
-	}
-	if !isPlayer1Turn && isPlayer1Switching {
-		return nil, fmt.Errorf("not your turn - it's player2's turn")
-	}
-
-	// Get player's team
-	playerTeam, err := s.DBQueries.GetUserTeam(ctx, req.PlayerID)
+	actions, err := q.GetTurnActions(ctx, game_db.GetTurnActionsParams{
+		BattleID: battle.ID,
+		Turn:     state.Turn,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get player team: %w", err)
-	}
-
-	// Find the pokemon at the requested position
-	var targetPokemon *game_db.UserTeam
-	for _, poke := range playerTeam {
-		if poke.Position == req.NewPosition {
-			targetPokemon = &poke
-			break
-		}
-	}
-
-	if targetPokemon == nil {
-		return nil, fmt.Errorf("no pokemon at position %d", req.NewPosition)
-	}
-
-	// Check if pokemon is fainted
-	if targetPokemon.IsFainted.Bool || targetPokemon.CurrentHp <= 0 {
-		return nil, fmt.Errorf("cannot switch to a fainted pokemon")
-	}
-
-	// Check if already active
-	var currentActivePos int32
-	if isPlayer1Switching {
-		currentActivePos = battle.Player1ActivePokemonPosition.Int32
-	} else {
-		currentActivePos = battle.Player2ActivePokemonPosition.Int32
-	}
-
-	if currentActivePos == req.NewPosition {
-		return nil, fmt.Errorf("pokemon is already active")
-	}
-
-	// Update the battle's active pokemon position
-	if isPlayer1Switching {
-		err = s.DBQueries.UpdatePlayer1ActivePokemon(ctx, game_db.UpdatePlayer1ActivePokemonParams{
-			ID:                           battle.ID,
-			Player1ActivePokemonPosition: pgtype.Int4{Int32: req.NewPosition, Valid: true},
-		})
-	} else {
-		err = s.DBQueries.UpdatePlayer2ActivePokemon(ctx, game_db.UpdatePlayer2ActivePokemonParams{
-			ID:                           battle.ID,
-			Player2ActivePokemonPosition: pgtype.Int4{Int32: req.NewPosition, Valid: true},
+		return state, fmt.Errorf("failed to get turn actions: %w", err)
+	}
+	for _, action := range actions {
+		state.Pending = append(state.Pending, engine.Action{
+			PlayerID: action.UserID,
+			Type:     action.ActionType,
+			MoveID:   action.MoveID.Int32,
+			Position: action.SwitchPosition.Int32,
 		})
 	}
-	if err != nil {
-		return nil, fmt.Errorf("failed to update active pokemon: %w", err)
-	}
 
-	// Advance turn
-	err = s.DBQueries.UpdateBattleTurn(ctx, req.BattleID)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to update battle turn")
-	}
-
-	// Re-read battle to get fresh active positions
-	updatedBattle, err := s.DBQueries.GetBattle(ctx, req.BattleID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to re-read battle: %w", err)
-	}
-
-	// Get updated teams
-	player1Team, err := s.DBQueries.GetUserTeam(ctx, battle.Player1ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get player1 team: %w", err)
-	}
-
-	player2Team, err := s.DBQueries.GetUserTeam(ctx, battle.Player2ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get player2 team: %w", err)
-	}
-
-	// Create message describing the action
-	message := fmt.Sprintf("Switched to species %d (position %d)", targetPokemon.PokemonSpeciesID.Int32, req.NewPosition)
-
-	// Check if battle is over (shouldn't happen on switch, but check anyway)
-	battleEnded, winnerID := s.checkBattleEnd(player1Team, player2Team, battle.Player1ID, battle.Player2ID)
-
-	log.Info().
-		Str("battle_id", req.BattleID.String()).
-		Str("player_id", req.PlayerID.String()).
-		Int32("new_position", req.NewPosition).
-		Msg("Pokemon switched successfully")
-
-	return &BattleStateResult{
-		BattleID:         req.BattleID,
-		Message:          message,
-		Player1ID:        battle.Player1ID,
-		Player1Team:      player1Team,
-		Player1ActivePos: updatedBattle.Player1ActivePokemonPosition.Int32,
-		Player2ID:        battle.Player2ID,
-		Player2Team:      player2Team,
-		Player2ActivePos: updatedBattle.Player2ActivePokemonPosition.Int32,
-		BattleEnded:      battleEnded,
-		WinnerID:         winnerID,
-	}, nil
+	return state, nil
 }
@@ -0,0 +1,99 @@
+package battle_s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// IdlePlayers returns the players that have not chosen their action for the given turn yet.
+// It is empty once the battle moved past that turn or is no longer active.
+func (s *BattleService) IdlePlayers(ctx context.Context, battleID pgtype.UUID, turn int32) ([]pgtype.UUID, error) {
+	battle, err := s.DBQueries.GetBattle(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBattleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle: %w", err)
+	}
+	if battle.Status.String != "active" || battle.CurrentTurn.Int32 != turn {
+		return nil, nil
+	}
+
+	actions, err := s.DBQueries.GetTurnActions(ctx, game_db.GetTurnActionsParams{
+		BattleID: battleID,
+		Turn:     turn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get turn actions: %w", err)
+	}
+
+	idle := []pgtype.UUID{}
+	for _, playerID := range []pgtype.UUID{battle.Player1ID, battle.Player2ID} {
+		acted := false
+		for _, action := range actions {
+			if action.UserID == playerID {
+				acted = true
+			}
+		}
+		if !acted {
+			idle = append(idle, playerID)
+		}
+	}
+	return idle, nil
+}
+
+// SwitchingPlayers returns the players that still have to replace their fainted active pokemon
+// before the given turn goes on. It is empty once the battle moved past that turn or is no longer active.
+func (s *BattleService) SwitchingPlayers(ctx context.Context, battleID pgtype.UUID, turn int32) ([]pgtype.UUID, error) {
+	battle, err := s.DBQueries.GetBattle(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBattleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle: %w", err)
+	}
+	if battle.Status.String != "active" || battle.CurrentTurn.Int32 != turn {
+		return nil, nil
+	}
+
+	state, err := loadState(ctx, s.DBQueries, battle)
+	if err != nil {
+		return nil, err
+	}
+
+	switching := []pgtype.UUID{}
+	for _, playerID := range []pgtype.UUID{battle.Player1ID, battle.Player2ID} {
+		if state.MustSwitch(playerID) {
+			switching = append(switching, playerID)
+		}
+	}
+	return switching, nil
+}
+
+// DefaultAction returns the action taken for a player that ran out of time, see engine.DefaultAction
+func (s *BattleService) DefaultAction(ctx context.Context, battleID, playerID pgtype.UUID) (TurnAction, error) {
+	battle, err := s.DBQueries.GetBattle(ctx, battleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TurnAction{}, ErrBattleNotFound
+	}
+	if err != nil {
+		return TurnAction{}, fmt.Errorf("failed to get battle: %w", err)
+	}
+
+	state, err := loadState(ctx, s.DBQueries, battle)
+	if err != nil {
+		return TurnAction{}, err
+	}
+
+	action, err := engine.DefaultAction(state, playerID)
+	if err != nil {
+		return TurnAction{}, err
+	}
+	return TurnAction{Type: action.Type, MoveID: action.MoveID, Position: action.Position}, nil
+}
@@ -0,0 +1,305 @@
+package battle_s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/DanielRasho/PokeSocket/internal/engine"
+	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// TurnAction is the action a player chose for the current turn
+type TurnAction struct {
+	Type     string // engine.ACTION_ATTACK or engine.ACTION_SWITCH
+	MoveID   int32  // Move to use on engine.ACTION_ATTACK
+	Position int32  // Team position to send out on engine.ACTION_SWITCH
+}
+
+// SubmitActionRequest contains all data needed to submit a turn action
+type SubmitActionRequest struct {
+	BattleID pgtype.UUID
+	PlayerID pgtype.UUID
+	Action   TurnAction
+}
+
+// SubmitAction registers the action of a player for the current turn.
+// Once both players submitted their action the turn is resolved and the new battle state returned,
+// until then the result only has TurnResolved set to false.
+func (s *BattleService) SubmitAction(ctx context.Context, req SubmitActionRequest) (*BattleStateResult, error) {
+	rules, err := s.battleEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DBClient.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.DBQueries.WithTx(tx)
+
+	// Lock the battle so both players' actions are resolved only once
+	locked, err := q.LockBattle(ctx, req.BattleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBattleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle: %w", err)
+	}
+	battle := game_db.GetBattleRow(locked)
+
+	if battle.Status.String != "active" {
+		return nil, fmt.Errorf("battle is not active")
+	}
+
+	state, err := loadState(ctx, q, battle)
+	if err != nil {
+		return nil, err
+	}
+
+	action, err := rules.Validate(state, engine.Action{
+		PlayerID: req.PlayerID,
+		Type:     req.Action.Type,
+		MoveID:   req.Action.MoveID,
+		Position: req.Action.Position,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The validated action is the one saved, a move chosen without PP left became Struggle
+	forced := state.MustSwitch(req.PlayerID)
+	next, events, err := rules.ApplyValidated(state, action)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replacing a fainted pokemon takes no turn, it is logged ahead of the turn's actions
+	if forced {
+		result, err := replaceFainted(ctx, q, battle.ID, state, next, action, events)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		log.Info().
+			Str("battle_id", battle.ID.String()).
+			Str("player_id", req.PlayerID.String()).
+			Int32("turn", state.Turn).
+			Int32("position", action.Position).
+			Msg("Fainted pokemon replaced")
+
+		return result, nil
+	}
+
+	turn := state.Turn
+	params := game_db.InsertBattleActionParams{
+		BattleID:   battle.ID,
+		Turn:       turn,
+		UserID:     req.PlayerID,
+		ActionType: action.Type,
+	}
+	if action.Type == engine.ACTION_ATTACK {
+		params.MoveID = pgtype.Int4{Int32: action.MoveID, Valid: true}
+	} else {
+		params.SwitchPosition = pgtype.Int4{Int32: action.Position, Valid: true}
+	}
+	if err := q.InsertBattleAction(ctx, params); err != nil {
+		return nil, fmt.Errorf("failed to save action: %w", err)
+	}
+
+	// Wait for the opponent
+	if next.Turn == turn {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		log.Info().
+			Str("battle_id", battle.ID.String()).
+			Str("player_id", req.PlayerID.String()).
+			Int32("turn", turn).
+			Str("action", action.Type).
+			Msg("Action submitted, waiting for opponent")
+
+		return &BattleStateResult{
+			BattleID:     battle.ID,
+			Turn:         turn,
+			TurnResolved: false,
+			Message:      "Action received! Waiting for opponent...",
+			Player1ID:    battle.Player1ID,
+			Player2ID:    battle.Player2ID,
+		}, nil
+	}
+
+	if err := saveState(ctx, q, battle.ID, state, next); err != nil {
+		return nil, err
+	}
+
+	actions := append(state.Pending, action)
+	if err := logTurn(ctx, q, battle.ID, turn, actions, events); err != nil {
+		return nil, err
+	}
+
+	// Check if battle is over (all pokemon fainted)
+	winnerID, battleEnded := next.Winner()
+	endReason := ""
+	if battleEnded {
+		loserID := battle.Player1ID
+		if winnerID == battle.Player1ID {
+			loserID = battle.Player2ID
+		}
+		endReason = END_REASON_ALL_FAINTED
+		if err := finishBattle(ctx, q, battle.ID, turn, winnerID, loserID, endReason); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	messages := make([]string, len(events))
+	for i, event := range events {
+		messages[i] = event.Message
+	}
+
+	log.Info().
+		Str("battle_id", battle.ID.String()).
+		Int32("turn", turn).
+		Int("events", len(events)).
+		Bool("battle_ended", battleEnded).
+		Msg("Turn resolved")
+
+	result := stateResult(battle.ID, next)
+	result.Turn = turn
+	result.TurnResolved = true
+	result.Message = strings.Join(messages, " ")
+	result.Events = events
+	result.BattleEnded = battleEnded
+	result.WinnerID = winnerID
+	result.EndReason = endReason
+	return result, nil
+}
+
+// replaceFainted saves a switch that replaced a fainted pokemon and returns the battle state
+// right after it. The turn stays the same, the players choose their actions for it next.
+func replaceFainted(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, before, after engine.BattleState, action engine.Action, events []engine.Event) (*BattleStateResult, error) {
+	if err := saveState(ctx, q, battleID, before, after); err != nil {
+		return nil, err
+	}
+	if err := logTurn(ctx, q, battleID, before.Turn, []engine.Action{action}, events); err != nil {
+		return nil, err
+	}
+
+	result := stateResult(battleID, after)
+	result.TurnResolved = true
+	result.ForcedSwitch = true
+	result.Message = events[0].Message
+	result.Events = events
+	return result, nil
+}
+
+// saveState persists what changed between two states of a battle: HP, PP,
+// active pokemon and the turn
+func saveState(ctx context.Context, q *game_db.Queries, battleID pgtype.UUID, before, after engine.BattleState) error {
+	sides := [][2]engine.Side{{before.Player1, after.Player1}, {before.Player2, after.Player2}}
+	for _, pair := range sides {
+		old, side := pair[0], pair[1]
+		for _, poke := range side.Team {
+			loaded := old.Pokemon(poke.Position)
+			if loaded == nil {
+				continue
+			}
+			if err := saveMovePP(ctx, q, battleID, side.PlayerID, *loaded, poke); err != nil {
+				return err
+			}
+			if loaded.Status != poke.Status || loaded.StatusTurns != poke.StatusTurns {
+				err := q.UpdateBattlePokemonStatus(ctx, game_db.UpdateBattlePokemonStatusParams{
+					Status:      poke.Status,
+					StatusTurns: poke.StatusTurns,
+					BattleID:    battleID,
+					UserID:      side.PlayerID,
+					Position:    poke.Position,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to update pokemon status: %w", err)
+				}
+			}
+			if loaded.Recharging != poke.Recharging {
+				err := q.UpdateBattlePokemonRecharging(ctx, game_db.UpdateBattlePokemonRechargingParams{
+					Recharging: poke.Recharging,
+					BattleID:   battleID,
+					UserID:     side.PlayerID,
+					Position:   poke.Position,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to update pokemon recharge: %w", err)
+				}
+			}
+			if !maps.Equal(loaded.Stages, poke.Stages) {
+				// Switching out resets the stages to nil, stored as an empty object
+				stages := []byte("{}")
+				if poke.Stages != nil {
+					encoded, err := json.Marshal(poke.Stages)
+					if err != nil {
+						return fmt.Errorf("failed to encode stat stages: %w", err)
+					}
+					stages = encoded
+				}
+				err := q.UpdateBattlePokemonStages(ctx, game_db.UpdateBattlePokemonStagesParams{
+					Stages:   stages,
+					BattleID: battleID,
+					UserID:   side.PlayerID,
+					Position: poke.Position,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to update stat stages: %w", err)
+				}
+			}
+			if loaded.HP == poke.HP {
+				continue
+			}
+			err := q.UpdateBattlePokemonHP(ctx, game_db.UpdateBattlePokemonHPParams{
+				BattleID:  battleID,
+				UserID:    side.PlayerID,
+				Position:  poke.Position,
+				CurrentHp: poke.HP,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update pokemon HP: %w", err)
+			}
+		}
+	}
+
+	err := q.UpdatePlayer1ActivePokemon(ctx, game_db.UpdatePlayer1ActivePokemonParams{
+		ID:                           battleID,
+		Player1ActivePokemonPosition: pgtype.Int4{Int32: after.Player1.ActivePos, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update active pokemon: %w", err)
+	}
+	err = q.UpdatePlayer2ActivePokemon(ctx, game_db.UpdatePlayer2ActivePokemonParams{
+		ID:                           battleID,
+		Player2ActivePokemonPosition: pgtype.Int4{Int32: after.Player2.ActivePos, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update active pokemon: %w", err)
+	}
+
+	if after.Turn != before.Turn {
+		if err := q.UpdateBattleTurn(ctx, battleID); err != nil {
+			return fmt.Errorf("failed to update battle turn: %w", err)
+		}
+	}
+	return nil
+}
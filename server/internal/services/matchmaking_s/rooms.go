@@ -0,0 +1,126 @@
+package matchmaking_s
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+// Characters of a room code, leaving out the ones easy to mix up (0/O, 1/I)
+const ROOM_CODE_ALPHABET = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const ROOM_CODE_LENGTH = 6
+
+// Room is a private battle waiting for the player that got its code
+type Room struct {
+	Code string
+	Host QueuedPlayer
+}
+
+// CreateRoom opens a private room hosted by the player and returns its join code.
+// A player can host a single room at a time, and not while waiting in a queue.
+func (m *MatchmakingService) CreateRoom(playerID pgtype.UUID, username string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkNotWaiting(playerID); err != nil {
+		return "", err
+	}
+
+	code, err := m.newRoomCode()
+	if err != nil {
+		return "", err
+	}
+	m.rooms[code] = &Room{
+		Code: code,
+		Host: QueuedPlayer{PlayerID: playerID, Username: username},
+	}
+
+	log.Info().
+		Str("player_id", playerID.String()).
+		Str("code", code).
+		Msg("Private room created")
+
+	return code, nil
+}
+
+// JoinRoom closes the room with the given code and returns its host,
+// who the player is going to battle. A player waiting in a queue or in their own room
+// can't join one, or they would be matched twice.
+func (m *MatchmakingService) JoinRoom(code string, playerID pgtype.UUID) (*QueuedPlayer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, exists := m.rooms[strings.ToUpper(code)]
+	if !exists {
+		return nil, fmt.Errorf("room not found")
+	}
+	if room.Host.PlayerID == playerID {
+		return nil, fmt.Errorf("you can't join your own room")
+	}
+	if err := m.checkNotWaiting(playerID); err != nil {
+		return nil, err
+	}
+	if room.Host.Away {
+		return nil, fmt.Errorf("room host is not connected")
+	}
+	delete(m.rooms, room.Code)
+
+	log.Info().
+		Str("player_id", playerID.String()).
+		Str("host_id", room.Host.PlayerID.String()).
+		Str("code", room.Code).
+		Msg("Private room joined")
+
+	host := room.Host
+	return &host, nil
+}
+
+// CancelRoom closes the room hosted by the player and returns its code
+func (m *MatchmakingService) CancelRoom(playerID pgtype.UUID) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for code, room := range m.rooms {
+		if room.Host.PlayerID == playerID {
+			delete(m.rooms, code)
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("you have no room")
+}
+
+// ExpireRoom closes the room with the given code if nobody joined it yet,
+// returning its host
+func (m *MatchmakingService) ExpireRoom(code string) (*QueuedPlayer, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, exists := m.rooms[code]
+	if !exists {
+		return nil, false
+	}
+	delete(m.rooms, code)
+	host := room.Host
+	return &host, true
+}
+
+// newRoomCode returns a random code no open room uses
+func (m *MatchmakingService) newRoomCode() (string, error) {
+	for {
+		random := make([]byte, ROOM_CODE_LENGTH)
+		if _, err := rand.Read(random); err != nil {
+			return "", fmt.Errorf("failed to generate room code: %w", err)
+		}
+		code := make([]byte, ROOM_CODE_LENGTH)
+		for i, b := range random {
+			code[i] = ROOM_CODE_ALPHABET[int(b)%len(ROOM_CODE_ALPHABET)]
+		}
+		if _, taken := m.rooms[string(code)]; !taken {
+			return string(code), nil
+		}
+	}
+}
@@ -1,39 +1,82 @@
 package matchmaking_s
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog/log"
 )
 
+// A ranked player is first only matched with players this many rating points away
+const RANKED_INITIAL_WINDOW = 100
+
+// Rating points the window of a ranked player widens every second they wait
+const RANKED_WINDOW_GROWTH = 10
+
+// The window of a ranked player stops widening at this many rating points
+const RANKED_MAX_WINDOW = 600
+
+// Estimated wait for ranked players until a match was made
+const RANKED_DEFAULT_WAIT = 30 * time.Second
+
+// How many of the last ranked waits the estimated wait is averaged from
+const RANKED_WAIT_SAMPLES = 20
+
 type QueuedPlayer struct {
 	PlayerID pgtype.UUID
 	Username string
+	Away     bool  // Keeps the place in the queue but can't be matched
+	Rating   int32 // Only for the ranked queue
+	JoinedAt time.Time
+}
+
+// SearchWindow is the range of ratings a ranked player can be matched with
+type SearchWindow struct {
+	MinRating int32
+	MaxRating int32
+}
+
+// RankedMatch is a pair of ranked players whose search windows met while waiting
+type RankedMatch struct {
+	Player1 QueuedPlayer // The one that waited the longest
+	Player2 QueuedPlayer
 }
 
 type MatchmakingService struct {
-	queue []QueuedPlayer
-	mu    sync.Mutex
+	queue       []QueuedPlayer
+	rankedQueue []QueuedPlayer
+	rankedWaits []time.Duration  // Last waits of matched ranked players
+	rooms       map[string]*Room // By join code
+	mu          sync.Mutex
 }
 
 func NewMatchmakingService() *MatchmakingService {
 	return &MatchmakingService{
-		queue: make([]QueuedPlayer, 0),
+		queue:       make([]QueuedPlayer, 0),
+		rankedQueue: make([]QueuedPlayer, 0),
+		rooms:       make(map[string]*Room),
 	}
 }
 
 // EnterQueue tries to match the player with someone in the queue.
 // If no one is waiting, adds the player to the queue.
 // Returns the matched opponent if found, or nil if added to queue.
-func (m *MatchmakingService) EnterQueue(playerID pgtype.UUID, username string) *QueuedPlayer {
+func (m *MatchmakingService) EnterQueue(playerID pgtype.UUID, username string) (*QueuedPlayer, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := m.checkNotWaiting(playerID); err != nil {
+		return nil, err
+	}
+
 	// Check if there's someone waiting in the queue
-	if len(m.queue) > 0 {
-		opponent := m.queue[0]
-		m.queue = m.queue[1:]
+	for i, opponent := range m.queue {
+		if opponent.Away || opponent.PlayerID == playerID {
+			continue
+		}
+		m.queue = append(m.queue[:i], m.queue[i+1:]...)
 
 		log.Info().
 			Str("player1_id", opponent.PlayerID.String()).
@@ -42,42 +85,303 @@ func (m *MatchmakingService) EnterQueue(playerID pgtype.UUID, username string) *
 			Str("player2_username", username).
 			Msg("Match found! Two players matched")
 
-		return &opponent
+		return &opponent, nil
 	}
 
 	// If no one found add it to the queue
 	m.queue = append(m.queue, QueuedPlayer{
 		PlayerID: playerID,
 		Username: username,
+		JoinedAt: time.Now(),
 	})
 
 	log.Info().
 		Str("player_id", playerID.String()).
 		Str("username", username).
-		Int("queue_size", len(m.queue)).
+		Int("queue_size", availableCount(m.queue)).
 		Msg("Player entered matchmaking queue")
 
+	return nil, nil
+}
+
+// EnterRankedQueue tries to match the player with the closest rated player waiting
+// whose search window covers them. If there is none, adds the player to the ranked
+// queue and returns their search window.
+func (m *MatchmakingService) EnterRankedQueue(playerID pgtype.UUID, username string, rating int32) (*QueuedPlayer, SearchWindow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkNotWaiting(playerID); err != nil {
+		return nil, SearchWindow{}, err
+	}
+
+	now := time.Now()
+	player := QueuedPlayer{
+		PlayerID: playerID,
+		Username: username,
+		Rating:   rating,
+		JoinedAt: now,
+	}
+
+	best := -1
+	for i, opponent := range m.rankedQueue {
+		if opponent.Away || opponent.PlayerID == playerID || !canMatch(opponent, player, now) {
+			continue
+		}
+		if best == -1 || ratingGap(opponent, player) < ratingGap(m.rankedQueue[best], player) {
+			best = i
+		}
+	}
+
+	if best != -1 {
+		opponent := m.rankedQueue[best]
+		m.rankedQueue = append(m.rankedQueue[:best], m.rankedQueue[best+1:]...)
+		m.recordWait(now.Sub(opponent.JoinedAt))
+
+		log.Info().
+			Str("player1_id", opponent.PlayerID.String()).
+			Int32("player1_rating", opponent.Rating).
+			Str("player2_id", playerID.String()).
+			Int32("player2_rating", rating).
+			Msg("Ranked match found")
+
+		return &opponent, searchWindow(player, now), nil
+	}
+
+	m.rankedQueue = append(m.rankedQueue, player)
+
+	log.Info().
+		Str("player_id", playerID.String()).
+		Str("username", username).
+		Int32("rating", rating).
+		Int("queue_size", availableCount(m.rankedQueue)).
+		Msg("Player entered ranked queue")
+
+	return nil, searchWindow(player, now), nil
+}
+
+// MatchWaitingRanked pairs the ranked players whose search windows widened enough
+// to cover each other while they waited
+func (m *MatchmakingService) MatchWaitingRanked() []RankedMatch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	matches := []RankedMatch{}
+	matched := make(map[int]bool)
+
+	// The queue is ordered by arrival, so those waiting the longest are served first
+	for i, player := range m.rankedQueue {
+		if matched[i] || player.Away {
+			continue
+		}
+		best := -1
+		for j := i + 1; j < len(m.rankedQueue); j++ {
+			opponent := m.rankedQueue[j]
+			if matched[j] || opponent.Away || !canMatch(player, opponent, now) {
+				continue
+			}
+			if best == -1 || ratingGap(player, opponent) < ratingGap(player, m.rankedQueue[best]) {
+				best = j
+			}
+		}
+		if best == -1 {
+			continue
+		}
+		matched[i], matched[best] = true, true
+		matches = append(matches, RankedMatch{Player1: player, Player2: m.rankedQueue[best]})
+		m.recordWait(now.Sub(player.JoinedAt))
+		m.recordWait(now.Sub(m.rankedQueue[best].JoinedAt))
+	}
+
+	if len(matches) == 0 {
+		return matches
+	}
+
+	remaining := make([]QueuedPlayer, 0, len(m.rankedQueue)-2*len(matches))
+	for i, player := range m.rankedQueue {
+		if !matched[i] {
+			remaining = append(remaining, player)
+		}
+	}
+	m.rankedQueue = remaining
+
+	log.Info().
+		Int("matches", len(matches)).
+		Int("queue_size", availableCount(m.rankedQueue)).
+		Msg("Ranked players matched after waiting")
+
+	return matches
+}
+
+// EstimatedRankedWait returns how long a ranked player can expect to wait,
+// based on how long the last matched ranked players waited
+func (m *MatchmakingService) EstimatedRankedWait() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.rankedWaits) == 0 {
+		return RANKED_DEFAULT_WAIT
+	}
+	var total time.Duration
+	for _, wait := range m.rankedWaits {
+		total += wait
+	}
+	return total / time.Duration(len(m.rankedWaits))
+}
+
+func (m *MatchmakingService) recordWait(wait time.Duration) {
+	m.rankedWaits = append(m.rankedWaits, wait)
+	if len(m.rankedWaits) > RANKED_WAIT_SAMPLES {
+		m.rankedWaits = m.rankedWaits[1:]
+	}
+}
+
+// RemoveFromQueue takes the player out of whichever queue they are waiting in.
+// Returns false if they were not waiting.
+func (m *MatchmakingService) RemoveFromQueue(playerID pgtype.UUID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, queue := range []*[]QueuedPlayer{&m.queue, &m.rankedQueue} {
+		for i, player := range *queue {
+			if player.PlayerID == playerID {
+				*queue = append((*queue)[:i], (*queue)[i+1:]...)
+				log.Info().
+					Str("player_id", playerID.String()).
+					Str("username", player.Username).
+					Msg("Player removed from matchmaking queue")
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RemoveExpired takes out of both queues the players that waited longer than maxWait
+// and returns them
+func (m *MatchmakingService) RemoveExpired(maxWait time.Duration) []QueuedPlayer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	expired := []QueuedPlayer{}
+	for _, queue := range []*[]QueuedPlayer{&m.queue, &m.rankedQueue} {
+		remaining := make([]QueuedPlayer, 0, len(*queue))
+		for _, player := range *queue {
+			if now.Sub(player.JoinedAt) > maxWait {
+				expired = append(expired, player)
+			} else {
+				remaining = append(remaining, player)
+			}
+		}
+		*queue = remaining
+	}
+
+	for _, player := range expired {
+		log.Info().
+			Str("player_id", player.PlayerID.String()).
+			Str("username", player.Username).
+			Msg("Player waited too long, removed from matchmaking queue")
+	}
+	return expired
+}
+
+// CheckNotWaiting fails if the player is already waiting in a queue or in their room
+func (m *MatchmakingService) CheckNotWaiting(playerID pgtype.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkNotWaiting(playerID)
+}
+
+// checkNotWaiting fails if the player is already waiting in a queue or in their room,
+// so they can't be matched twice or against themselves
+func (m *MatchmakingService) checkNotWaiting(playerID pgtype.UUID) error {
+	for _, queue := range [][]QueuedPlayer{m.queue, m.rankedQueue} {
+		for _, player := range queue {
+			if player.PlayerID == playerID {
+				return fmt.Errorf("already in matchmaking queue")
+			}
+		}
+	}
+	for _, room := range m.rooms {
+		if room.Host.PlayerID == playerID {
+			return fmt.Errorf("already waiting in room %s", room.Code)
+		}
+	}
 	return nil
 }
 
-func (m *MatchmakingService) RemoveFromQueue(playerID pgtype.UUID) {
+// SetAway marks a queued player as temporarily unavailable, e.g. while they
+// reconnect. They keep their place in the queue, or their room, but are not
+// matched meanwhile.
+func (m *MatchmakingService) SetAway(playerID pgtype.UUID, away bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	for i, player := range m.queue {
-		if player.PlayerID == playerID {
-			m.queue = append(m.queue[:i], m.queue[i+1:]...)
-			log.Info().
-				Str("player_id", playerID.String()).
-				Str("username", player.Username).
-				Msg("Player removed from matchmaking queue")
-			return
+	for _, room := range m.rooms {
+		if room.Host.PlayerID == playerID {
+			room.Host.Away = away
+		}
+	}
+
+	for _, queue := range [][]QueuedPlayer{m.queue, m.rankedQueue} {
+		for i := range queue {
+			if queue[i].PlayerID == playerID {
+				queue[i].Away = away
+				return
+			}
 		}
 	}
 }
 
+// GetQueueSize returns how many players are waiting and can be matched
 func (m *MatchmakingService) GetQueueSize() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return len(m.queue)
+	return availableCount(m.queue)
+}
+
+// GetRankedQueueSize returns how many ranked players are waiting and can be matched
+func (m *MatchmakingService) GetRankedQueueSize() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return availableCount(m.rankedQueue)
+}
+
+func availableCount(queue []QueuedPlayer) int {
+	count := 0
+	for _, player := range queue {
+		if !player.Away {
+			count++
+		}
+	}
+	return count
+}
+
+// window returns how many rating points away the player accepts opponents
+func window(player QueuedPlayer, now time.Time) int32 {
+	waited := int32(now.Sub(player.JoinedAt) / time.Second)
+	return min(RANKED_INITIAL_WINDOW+RANKED_WINDOW_GROWTH*waited, RANKED_MAX_WINDOW)
+}
+
+func searchWindow(player QueuedPlayer, now time.Time) SearchWindow {
+	w := window(player, now)
+	return SearchWindow{
+		MinRating: player.Rating - w,
+		MaxRating: player.Rating + w,
+	}
+}
+
+func ratingGap(a, b QueuedPlayer) int32 {
+	if a.Rating > b.Rating {
+		return a.Rating - b.Rating
+	}
+	return b.Rating - a.Rating
+}
+
+// canMatch reports whether the players are close enough in rating. The widest of both
+// windows is used, so a player that waited long accepts those that just arrived.
+func canMatch(a, b QueuedPlayer, now time.Time) bool {
+	return ratingGap(a, b) <= max(window(a, now), window(b, now))
 }
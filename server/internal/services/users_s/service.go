@@ -3,6 +3,7 @@ package users_s
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 
 	"github.com/DanielRasho/PokeSocket/internal/storage/postgres_cli/game_db"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -83,3 +84,20 @@ type User struct {
 func (s *UserService) GetUser(ctx context.Context, userId pgtype.UUID) error {
 	return s.DBQueries.DeleteUser(ctx, userId)
 }
+
+// RandomTeam returns size different pokemon species picked at random
+func (s *UserService) RandomTeam(ctx context.Context, size int) ([]int, error) {
+	ids, err := s.DBQueries.ListPokemonSpeciesIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pokemon species: %w", err)
+	}
+	if len(ids) < size {
+		return nil, fmt.Errorf("not enough pokemon species for a team of %d", size)
+	}
+
+	team := make([]int, size)
+	for i, j := range rand.Perm(len(ids))[:size] {
+		team[i] = int(ids[j])
+	}
+	return team, nil
+}
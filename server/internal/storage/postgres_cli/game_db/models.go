@@ -19,6 +19,62 @@ type Battle struct {
 	Player2ActivePokemonPosition pgtype.Int4
 	StartedAt                    pgtype.Timestamp
 	EndedAt                      pgtype.Timestamp
+	RngSeed                      int64
+	Ranked                       bool
+}
+
+type BattleAction struct {
+	BattleID       pgtype.UUID
+	Turn           int32
+	UserID         pgtype.UUID
+	ActionType     string
+	MoveID         pgtype.Int4
+	SwitchPosition pgtype.Int4
+	SubmittedAt    pgtype.Timestamp
+}
+
+type BattleEvent struct {
+	BattleID      pgtype.UUID
+	Sequence      int32
+	Turn          int32
+	EventType     string
+	PlayerID      pgtype.UUID
+	Position      pgtype.Int4
+	SpeciesID     pgtype.Int4
+	ActionType    pgtype.Text
+	MoveID        pgtype.Int4
+	Damage        pgtype.Int4
+	RemainingHp   pgtype.Int4
+	Effectiveness pgtype.Text
+	CriticalHit   bool
+	EndReason     pgtype.Text
+	Message       string
+	CreatedAt     pgtype.Timestamp
+	Status        pgtype.Text
+	Stat          pgtype.Text
+	StatChange    pgtype.Int4
+}
+
+type BattleMovePp struct {
+	BattleID    pgtype.UUID
+	UserID      pgtype.UUID
+	Position    int32
+	MoveID      int32
+	RemainingPp int32
+}
+
+type BattlePokemon struct {
+	ID               int32
+	BattleID         pgtype.UUID
+	UserID           pgtype.UUID
+	PokemonSpeciesID pgtype.Int4
+	Position         int32
+	CurrentHp        int32
+	IsFainted        pgtype.Bool
+	Status           string
+	StatusTurns      int32
+	Recharging       bool
+	Stages           []byte
 }
 
 type BattleResult struct {
@@ -30,6 +86,8 @@ type BattleResult struct {
 	TotalTurns      pgtype.Int4
 	DurationSeconds pgtype.Int4
 	CompletedAt     pgtype.Timestamp
+	WinnerUsername  pgtype.Text
+	LoserUsername   pgtype.Text
 }
 
 type Move struct {
@@ -41,6 +99,25 @@ type Move struct {
 	Pp                int32
 	EffectDescription pgtype.Text
 	CreatedAt         pgtype.Timestamp
+	Priority          int32
+}
+
+type MoveEffect struct {
+	ID        int32
+	MoveID    int32
+	Kind      string
+	Target    string
+	Chance    int32
+	Magnitude int32
+	Status    pgtype.Text
+	Stat      pgtype.Text
+}
+
+type PlayerRating struct {
+	Username    string
+	Rating      int32
+	GamesPlayed int32
+	UpdatedAt   pgtype.Timestamp
 }
 
 type PokemonMove struct {
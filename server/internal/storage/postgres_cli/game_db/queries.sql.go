@@ -12,15 +12,17 @@ import (
 )
 
 const createBattle = `-- name: CreateBattle :one
-INSERT INTO battles (id, player1_id, player2_id, status, player1_active_pokemon_position, player2_active_pokemon_position)
-VALUES ($1, $2, $3, 'active', 1, 1)
-RETURNING id, player1_id, player2_id, status, started_at
+INSERT INTO battles (id, player1_id, player2_id, status, player1_active_pokemon_position, player2_active_pokemon_position, rng_seed, ranked)
+VALUES ($1, $2, $3, 'active', 1, 1, $4, $5)
+RETURNING id, player1_id, player2_id, status, started_at, rng_seed, ranked
 `
 
 type CreateBattleParams struct {
 	ID        pgtype.UUID
 	Player1ID pgtype.UUID
 	Player2ID pgtype.UUID
+	RngSeed   int64
+	Ranked    bool
 }
 
 type CreateBattleRow struct {
@@ -29,10 +31,18 @@ type CreateBattleRow struct {
 	Player2ID pgtype.UUID
 	Status    pgtype.Text
 	StartedAt pgtype.Timestamp
+	RngSeed   int64
+	Ranked    bool
 }
 
 func (q *Queries) CreateBattle(ctx context.Context, arg CreateBattleParams) (CreateBattleRow, error) {
-	row := q.db.QueryRow(ctx, createBattle, arg.ID, arg.Player1ID, arg.Player2ID)
+	row := q.db.QueryRow(ctx, createBattle,
+		arg.ID,
+		arg.Player1ID,
+		arg.Player2ID,
+		arg.RngSeed,
+		arg.Ranked,
+	)
 	var i CreateBattleRow
 	err := row.Scan(
 		&i.ID,
@@ -40,20 +50,12 @@ func (q *Queries) CreateBattle(ctx context.Context, arg CreateBattleParams) (Cre
 		&i.Player2ID,
 		&i.Status,
 		&i.StartedAt,
+		&i.RngSeed,
+		&i.Ranked,
 	)
 	return i, err
 }
 
-const deleteBattle = `-- name: DeleteBattle :exec
-DELETE FROM battles
-WHERE id = $1
-`
-
-func (q *Queries) DeleteBattle(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteBattle, id)
-	return err
-}
-
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1
@@ -64,8 +66,29 @@ func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const endBattle = `-- name: EndBattle :one
+UPDATE battles
+SET status = 'completed',
+    winner_id = $1,
+    ended_at = CURRENT_TIMESTAMP
+WHERE id = $2
+RETURNING ranked
+`
+
+type EndBattleParams struct {
+	WinnerID pgtype.UUID
+	ID       pgtype.UUID
+}
+
+func (q *Queries) EndBattle(ctx context.Context, arg EndBattleParams) (bool, error) {
+	row := q.db.QueryRow(ctx, endBattle, arg.WinnerID, arg.ID)
+	var ranked bool
+	err := row.Scan(&ranked)
+	return ranked, err
+}
+
 const getBattle = `-- name: GetBattle :one
-SELECT id, player1_id, player2_id, status, current_turn, player1_active_pokemon_position, player2_active_pokemon_position
+SELECT id, player1_id, player2_id, status, current_turn, player1_active_pokemon_position, player2_active_pokemon_position, rng_seed
 FROM battles
 WHERE id = $1
 `
@@ -78,6 +101,7 @@ type GetBattleRow struct {
 	CurrentTurn                  pgtype.Int4
 	Player1ActivePokemonPosition pgtype.Int4
 	Player2ActivePokemonPosition pgtype.Int4
+	RngSeed                      int64
 }
 
 func (q *Queries) GetBattle(ctx context.Context, id pgtype.UUID) (GetBattleRow, error) {
@@ -91,10 +115,196 @@ func (q *Queries) GetBattle(ctx context.Context, id pgtype.UUID) (GetBattleRow,
 		&i.CurrentTurn,
 		&i.Player1ActivePokemonPosition,
 		&i.Player2ActivePokemonPosition,
+		&i.RngSeed,
 	)
 	return i, err
 }
 
+const getBattleEvents = `-- name: GetBattleEvents :many
+SELECT battle_id, sequence, turn, event_type, player_id, position, species_id, action_type, move_id, damage, remaining_hp, effectiveness, critical_hit, end_reason, message, created_at, status, stat, stat_change
+FROM battle_events
+WHERE battle_id = $1
+ORDER BY sequence
+`
+
+func (q *Queries) GetBattleEvents(ctx context.Context, battleID pgtype.UUID) ([]BattleEvent, error) {
+	rows, err := q.db.Query(ctx, getBattleEvents, battleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BattleEvent
+	for rows.Next() {
+		var i BattleEvent
+		if err := rows.Scan(
+			&i.BattleID,
+			&i.Sequence,
+			&i.Turn,
+			&i.EventType,
+			&i.PlayerID,
+			&i.Position,
+			&i.SpeciesID,
+			&i.ActionType,
+			&i.MoveID,
+			&i.Damage,
+			&i.RemainingHp,
+			&i.Effectiveness,
+			&i.CriticalHit,
+			&i.EndReason,
+			&i.Message,
+			&i.CreatedAt,
+			&i.Status,
+			&i.Stat,
+			&i.StatChange,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBattleMovePP = `-- name: GetBattleMovePP :many
+SELECT bmp.user_id, bmp.position, bmp.move_id, m.name, bmp.remaining_pp, m.pp AS max_pp
+FROM battle_move_pp bmp
+JOIN moves m ON m.id = bmp.move_id
+WHERE bmp.battle_id = $1
+ORDER BY bmp.user_id, bmp.position, bmp.move_id
+`
+
+type GetBattleMovePPRow struct {
+	UserID      pgtype.UUID
+	Position    int32
+	MoveID      int32
+	Name        string
+	RemainingPp int32
+	MaxPp       int32
+}
+
+func (q *Queries) GetBattleMovePP(ctx context.Context, battleID pgtype.UUID) ([]GetBattleMovePPRow, error) {
+	rows, err := q.db.Query(ctx, getBattleMovePP, battleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBattleMovePPRow
+	for rows.Next() {
+		var i GetBattleMovePPRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Position,
+			&i.MoveID,
+			&i.Name,
+			&i.RemainingPp,
+			&i.MaxPp,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBattleReplay = `-- name: GetBattleReplay :one
+SELECT id, status, ranked, rng_seed, current_turn, started_at, ended_at
+FROM battles
+WHERE id = $1
+`
+
+type GetBattleReplayRow struct {
+	ID          pgtype.UUID
+	Status      pgtype.Text
+	Ranked      bool
+	RngSeed     int64
+	CurrentTurn pgtype.Int4
+	StartedAt   pgtype.Timestamp
+	EndedAt     pgtype.Timestamp
+}
+
+func (q *Queries) GetBattleReplay(ctx context.Context, id pgtype.UUID) (GetBattleReplayRow, error) {
+	row := q.db.QueryRow(ctx, getBattleReplay, id)
+	var i GetBattleReplayRow
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.Ranked,
+		&i.RngSeed,
+		&i.CurrentTurn,
+		&i.StartedAt,
+		&i.EndedAt,
+	)
+	return i, err
+}
+
+const getBattleTeam = `-- name: GetBattleTeam :many
+SELECT id, battle_id, user_id, pokemon_species_id, position, current_hp, is_fainted, status, status_turns, recharging, stages
+FROM battle_pokemon
+WHERE battle_id = $1 AND user_id = $2
+ORDER BY position
+`
+
+type GetBattleTeamParams struct {
+	BattleID pgtype.UUID
+	UserID   pgtype.UUID
+}
+
+func (q *Queries) GetBattleTeam(ctx context.Context, arg GetBattleTeamParams) ([]BattlePokemon, error) {
+	rows, err := q.db.Query(ctx, getBattleTeam, arg.BattleID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BattlePokemon
+	for rows.Next() {
+		var i BattlePokemon
+		if err := rows.Scan(
+			&i.ID,
+			&i.BattleID,
+			&i.UserID,
+			&i.PokemonSpeciesID,
+			&i.Position,
+			&i.CurrentHp,
+			&i.IsFainted,
+			&i.Status,
+			&i.StatusTurns,
+			&i.Recharging,
+			&i.Stages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPlayerRating = `-- name: GetPlayerRating :one
+SELECT u.username, r.rating
+FROM users u
+LEFT JOIN player_ratings r ON r.username = u.username
+WHERE u.id = $1
+`
+
+type GetPlayerRatingRow struct {
+	Username string
+	Rating   pgtype.Int4
+}
+
+func (q *Queries) GetPlayerRating(ctx context.Context, userID pgtype.UUID) (GetPlayerRatingRow, error) {
+	row := q.db.QueryRow(ctx, getPlayerRating, userID)
+	var i GetPlayerRatingRow
+	err := row.Scan(&i.Username, &i.Rating)
+	return i, err
+}
+
 const getPokemonSpecies = `-- name: GetPokemonSpecies :one
 SELECT id, name, base_hp, base_attack, base_defense, base_speed, type1, type2
 FROM pokemon_species
@@ -128,6 +338,46 @@ func (q *Queries) GetPokemonSpecies(ctx context.Context, id int32) (GetPokemonSp
 	return i, err
 }
 
+const getTurnActions = `-- name: GetTurnActions :many
+SELECT battle_id, turn, user_id, action_type, move_id, switch_position, submitted_at
+FROM battle_actions
+WHERE battle_id = $1 AND turn = $2
+ORDER BY submitted_at
+`
+
+type GetTurnActionsParams struct {
+	BattleID pgtype.UUID
+	Turn     int32
+}
+
+func (q *Queries) GetTurnActions(ctx context.Context, arg GetTurnActionsParams) ([]BattleAction, error) {
+	rows, err := q.db.Query(ctx, getTurnActions, arg.BattleID, arg.Turn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BattleAction
+	for rows.Next() {
+		var i BattleAction
+		if err := rows.Scan(
+			&i.BattleID,
+			&i.Turn,
+			&i.UserID,
+			&i.ActionType,
+			&i.MoveID,
+			&i.SwitchPosition,
+			&i.SubmittedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserTeam = `-- name: GetUserTeam :many
 SELECT id, user_id, pokemon_species_id, position, current_hp, is_active, is_fainted
 FROM user_team
@@ -163,6 +413,127 @@ func (q *Queries) GetUserTeam(ctx context.Context, userID pgtype.UUID) ([]UserTe
 	return items, nil
 }
 
+const initBattleMovePP = `-- name: InitBattleMovePP :exec
+INSERT INTO battle_move_pp (battle_id, user_id, position, move_id, remaining_pp)
+SELECT bp.battle_id, bp.user_id, bp.position, m.id, m.pp
+FROM battle_pokemon bp
+JOIN pokemon_moves pm ON pm.pokemon_species_id = bp.pokemon_species_id
+JOIN moves m ON m.id = pm.move_id
+WHERE bp.battle_id = $1 AND bp.user_id = $2
+`
+
+type InitBattleMovePPParams struct {
+	BattleID pgtype.UUID
+	UserID   pgtype.UUID
+}
+
+func (q *Queries) InitBattleMovePP(ctx context.Context, arg InitBattleMovePPParams) error {
+	_, err := q.db.Exec(ctx, initBattleMovePP, arg.BattleID, arg.UserID)
+	return err
+}
+
+const insertBattleAction = `-- name: InsertBattleAction :exec
+INSERT INTO battle_actions (battle_id, turn, user_id, action_type, move_id, switch_position)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertBattleActionParams struct {
+	BattleID       pgtype.UUID
+	Turn           int32
+	UserID         pgtype.UUID
+	ActionType     string
+	MoveID         pgtype.Int4
+	SwitchPosition pgtype.Int4
+}
+
+func (q *Queries) InsertBattleAction(ctx context.Context, arg InsertBattleActionParams) error {
+	_, err := q.db.Exec(ctx, insertBattleAction,
+		arg.BattleID,
+		arg.Turn,
+		arg.UserID,
+		arg.ActionType,
+		arg.MoveID,
+		arg.SwitchPosition,
+	)
+	return err
+}
+
+const insertBattleEvent = `-- name: InsertBattleEvent :exec
+INSERT INTO battle_events (battle_id, sequence, turn, event_type, player_id, position, species_id, action_type, move_id, damage, remaining_hp, effectiveness, critical_hit, end_reason, message, status, stat, stat_change)
+SELECT $1, COALESCE(MAX(sequence), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+FROM battle_events
+WHERE battle_id = $1
+`
+
+type InsertBattleEventParams struct {
+	BattleID      pgtype.UUID
+	Turn          int32
+	EventType     string
+	PlayerID      pgtype.UUID
+	Position      pgtype.Int4
+	SpeciesID     pgtype.Int4
+	ActionType    pgtype.Text
+	MoveID        pgtype.Int4
+	Damage        pgtype.Int4
+	RemainingHp   pgtype.Int4
+	Effectiveness pgtype.Text
+	CriticalHit   bool
+	EndReason     pgtype.Text
+	Message       string
+	Status        pgtype.Text
+	Stat          pgtype.Text
+	StatChange    pgtype.Int4
+}
+
+func (q *Queries) InsertBattleEvent(ctx context.Context, arg InsertBattleEventParams) error {
+	_, err := q.db.Exec(ctx, insertBattleEvent,
+		arg.BattleID,
+		arg.Turn,
+		arg.EventType,
+		arg.PlayerID,
+		arg.Position,
+		arg.SpeciesID,
+		arg.ActionType,
+		arg.MoveID,
+		arg.Damage,
+		arg.RemainingHp,
+		arg.Effectiveness,
+		arg.CriticalHit,
+		arg.EndReason,
+		arg.Message,
+		arg.Status,
+		arg.Stat,
+		arg.StatChange,
+	)
+	return err
+}
+
+const insertBattleResult = `-- name: InsertBattleResult :exec
+INSERT INTO battle_results (battle_id, winner_id, winner_username, loser_id, loser_username, end_reason, total_turns, duration_seconds)
+SELECT b.id, $1, w.username, $2, l.username, $3, b.current_turn - 1, EXTRACT(EPOCH FROM (b.ended_at - b.started_at))::INTEGER
+FROM battles b
+LEFT JOIN users w ON w.id = $1
+LEFT JOIN users l ON l.id = $2
+WHERE b.id = $4
+`
+
+type InsertBattleResultParams struct {
+	WinnerID  pgtype.UUID
+	LoserID   pgtype.UUID
+	EndReason pgtype.Text
+	BattleID  pgtype.UUID
+}
+
+func (q *Queries) InsertBattleResult(ctx context.Context, arg InsertBattleResultParams) error {
+	_, err := q.db.Exec(ctx, insertBattleResult,
+		arg.WinnerID,
+		arg.LoserID,
+		arg.EndReason,
+		arg.BattleID,
+	)
+	return err
+}
+
 const insertUser = `-- name: InsertUser :one
 INSERT INTO users (id, username, status)
 VALUES ($1, $2, 'connected')
@@ -199,6 +570,350 @@ func (q *Queries) InsertUserTeamPokemon(ctx context.Context, arg InsertUserTeamP
 	return err
 }
 
+const listMoveEffects = `-- name: ListMoveEffects :many
+SELECT id, move_id, kind, target, chance, magnitude, status, stat
+FROM move_effects
+ORDER BY move_id, id
+`
+
+func (q *Queries) ListMoveEffects(ctx context.Context) ([]MoveEffect, error) {
+	rows, err := q.db.Query(ctx, listMoveEffects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MoveEffect
+	for rows.Next() {
+		var i MoveEffect
+		if err := rows.Scan(
+			&i.ID,
+			&i.MoveID,
+			&i.Kind,
+			&i.Target,
+			&i.Chance,
+			&i.Magnitude,
+			&i.Status,
+			&i.Stat,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoves = `-- name: ListMoves :many
+SELECT id, name, type, power, accuracy, pp, priority
+FROM moves
+ORDER BY id
+`
+
+type ListMovesRow struct {
+	ID       int32
+	Name     string
+	Type     string
+	Power    int32
+	Accuracy int32
+	Pp       int32
+	Priority int32
+}
+
+func (q *Queries) ListMoves(ctx context.Context) ([]ListMovesRow, error) {
+	rows, err := q.db.Query(ctx, listMoves)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMovesRow
+	for rows.Next() {
+		var i ListMovesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.Power,
+			&i.Accuracy,
+			&i.Pp,
+			&i.Priority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPokemonSpecies = `-- name: ListPokemonSpecies :many
+SELECT id, name, base_hp, base_attack, base_defense, base_speed, type1, type2
+FROM pokemon_species
+ORDER BY id
+`
+
+type ListPokemonSpeciesRow struct {
+	ID          int32
+	Name        string
+	BaseHp      int32
+	BaseAttack  int32
+	BaseDefense int32
+	BaseSpeed   int32
+	Type1       string
+	Type2       pgtype.Text
+}
+
+func (q *Queries) ListPokemonSpecies(ctx context.Context) ([]ListPokemonSpeciesRow, error) {
+	rows, err := q.db.Query(ctx, listPokemonSpecies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPokemonSpeciesRow
+	for rows.Next() {
+		var i ListPokemonSpeciesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.BaseHp,
+			&i.BaseAttack,
+			&i.BaseDefense,
+			&i.BaseSpeed,
+			&i.Type1,
+			&i.Type2,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPokemonSpeciesIDs = `-- name: ListPokemonSpeciesIDs :many
+SELECT id
+FROM pokemon_species
+ORDER BY id
+`
+
+func (q *Queries) ListPokemonSpeciesIDs(ctx context.Context) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listPokemonSpeciesIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSpeciesMoves = `-- name: ListSpeciesMoves :many
+SELECT pokemon_species_id, move_id
+FROM pokemon_moves
+ORDER BY pokemon_species_id, move_id
+`
+
+func (q *Queries) ListSpeciesMoves(ctx context.Context) ([]PokemonMove, error) {
+	rows, err := q.db.Query(ctx, listSpeciesMoves)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PokemonMove
+	for rows.Next() {
+		var i PokemonMove
+		if err := rows.Scan(&i.PokemonSpeciesID, &i.MoveID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockBattle = `-- name: LockBattle :one
+SELECT id, player1_id, player2_id, status, current_turn, player1_active_pokemon_position, player2_active_pokemon_position, rng_seed
+FROM battles
+WHERE id = $1
+FOR UPDATE
+`
+
+type LockBattleRow struct {
+	ID                           pgtype.UUID
+	Player1ID                    pgtype.UUID
+	Player2ID                    pgtype.UUID
+	Status                       pgtype.Text
+	CurrentTurn                  pgtype.Int4
+	Player1ActivePokemonPosition pgtype.Int4
+	Player2ActivePokemonPosition pgtype.Int4
+	RngSeed                      int64
+}
+
+func (q *Queries) LockBattle(ctx context.Context, id pgtype.UUID) (LockBattleRow, error) {
+	row := q.db.QueryRow(ctx, lockBattle, id)
+	var i LockBattleRow
+	err := row.Scan(
+		&i.ID,
+		&i.Player1ID,
+		&i.Player2ID,
+		&i.Status,
+		&i.CurrentTurn,
+		&i.Player1ActivePokemonPosition,
+		&i.Player2ActivePokemonPosition,
+		&i.RngSeed,
+	)
+	return i, err
+}
+
+const savePlayerRating = `-- name: SavePlayerRating :exec
+INSERT INTO player_ratings (username, rating, games_played, updated_at)
+VALUES ($1, $2, 1, CURRENT_TIMESTAMP)
+ON CONFLICT (username) DO UPDATE
+SET rating = EXCLUDED.rating,
+    games_played = player_ratings.games_played + 1,
+    updated_at = CURRENT_TIMESTAMP
+`
+
+type SavePlayerRatingParams struct {
+	Username string
+	Rating   int32
+}
+
+func (q *Queries) SavePlayerRating(ctx context.Context, arg SavePlayerRatingParams) error {
+	_, err := q.db.Exec(ctx, savePlayerRating, arg.Username, arg.Rating)
+	return err
+}
+
+const snapshotBattleTeam = `-- name: SnapshotBattleTeam :exec
+INSERT INTO battle_pokemon (battle_id, user_id, pokemon_species_id, position, current_hp, is_fainted)
+SELECT $1, ut.user_id, ut.pokemon_species_id, ut.position, ps.base_hp, false
+FROM user_team ut
+JOIN pokemon_species ps ON ps.id = ut.pokemon_species_id
+WHERE ut.user_id = $2
+`
+
+type SnapshotBattleTeamParams struct {
+	BattleID pgtype.UUID
+	UserID   pgtype.UUID
+}
+
+func (q *Queries) SnapshotBattleTeam(ctx context.Context, arg SnapshotBattleTeamParams) error {
+	_, err := q.db.Exec(ctx, snapshotBattleTeam, arg.BattleID, arg.UserID)
+	return err
+}
+
+const updateBattlePokemonHP = `-- name: UpdateBattlePokemonHP :exec
+UPDATE battle_pokemon
+SET current_hp = $1,
+    is_fainted = CASE WHEN $1 <= 0 THEN true ELSE is_fainted END
+WHERE battle_id = $2 AND user_id = $3 AND position = $4
+`
+
+type UpdateBattlePokemonHPParams struct {
+	CurrentHp int32
+	BattleID  pgtype.UUID
+	UserID    pgtype.UUID
+	Position  int32
+}
+
+func (q *Queries) UpdateBattlePokemonHP(ctx context.Context, arg UpdateBattlePokemonHPParams) error {
+	_, err := q.db.Exec(ctx, updateBattlePokemonHP,
+		arg.CurrentHp,
+		arg.BattleID,
+		arg.UserID,
+		arg.Position,
+	)
+	return err
+}
+
+const updateBattlePokemonRecharging = `-- name: UpdateBattlePokemonRecharging :exec
+UPDATE battle_pokemon
+SET recharging = $1
+WHERE battle_id = $2 AND user_id = $3 AND position = $4
+`
+
+type UpdateBattlePokemonRechargingParams struct {
+	Recharging bool
+	BattleID   pgtype.UUID
+	UserID     pgtype.UUID
+	Position   int32
+}
+
+func (q *Queries) UpdateBattlePokemonRecharging(ctx context.Context, arg UpdateBattlePokemonRechargingParams) error {
+	_, err := q.db.Exec(ctx, updateBattlePokemonRecharging,
+		arg.Recharging,
+		arg.BattleID,
+		arg.UserID,
+		arg.Position,
+	)
+	return err
+}
+
+const updateBattlePokemonStages = `-- name: UpdateBattlePokemonStages :exec
+UPDATE battle_pokemon
+SET stages = $1
+WHERE battle_id = $2 AND user_id = $3 AND position = $4
+`
+
+type UpdateBattlePokemonStagesParams struct {
+	Stages   []byte
+	BattleID pgtype.UUID
+	UserID   pgtype.UUID
+	Position int32
+}
+
+func (q *Queries) UpdateBattlePokemonStages(ctx context.Context, arg UpdateBattlePokemonStagesParams) error {
+	_, err := q.db.Exec(ctx, updateBattlePokemonStages,
+		arg.Stages,
+		arg.BattleID,
+		arg.UserID,
+		arg.Position,
+	)
+	return err
+}
+
+const updateBattlePokemonStatus = `-- name: UpdateBattlePokemonStatus :exec
+UPDATE battle_pokemon
+SET status = $1,
+    status_turns = $2
+WHERE battle_id = $3 AND user_id = $4 AND position = $5
+`
+
+type UpdateBattlePokemonStatusParams struct {
+	Status      string
+	StatusTurns int32
+	BattleID    pgtype.UUID
+	UserID      pgtype.UUID
+	Position    int32
+}
+
+func (q *Queries) UpdateBattlePokemonStatus(ctx context.Context, arg UpdateBattlePokemonStatusParams) error {
+	_, err := q.db.Exec(ctx, updateBattlePokemonStatus,
+		arg.Status,
+		arg.StatusTurns,
+		arg.BattleID,
+		arg.UserID,
+		arg.Position,
+	)
+	return err
+}
+
 const updateBattleTurn = `-- name: UpdateBattleTurn :exec
 UPDATE battles
 SET current_turn = current_turn + 1
@@ -242,20 +957,28 @@ func (q *Queries) UpdatePlayer2ActivePokemon(ctx context.Context, arg UpdatePlay
 	return err
 }
 
-const updatePokemonHP = `-- name: UpdatePokemonHP :exec
-UPDATE user_team
-SET current_hp = $1,
-    is_fainted = CASE WHEN $1 <= 0 THEN true ELSE is_fainted END
-WHERE user_id = $2 AND position = $3
+const useMovePP = `-- name: UseMovePP :one
+UPDATE battle_move_pp
+SET remaining_pp = remaining_pp - 1
+WHERE battle_id = $1 AND user_id = $2 AND position = $3 AND move_id = $4 AND remaining_pp > 0
+RETURNING remaining_pp
 `
 
-type UpdatePokemonHPParams struct {
-	CurrentHp int32
-	UserID    pgtype.UUID
-	Position  int32
+type UseMovePPParams struct {
+	BattleID pgtype.UUID
+	UserID   pgtype.UUID
+	Position int32
+	MoveID   int32
 }
 
-func (q *Queries) UpdatePokemonHP(ctx context.Context, arg UpdatePokemonHPParams) error {
-	_, err := q.db.Exec(ctx, updatePokemonHP, arg.CurrentHp, arg.UserID, arg.Position)
-	return err
+func (q *Queries) UseMovePP(ctx context.Context, arg UseMovePPParams) (int32, error) {
+	row := q.db.QueryRow(ctx, useMovePP,
+		arg.BattleID,
+		arg.UserID,
+		arg.Position,
+		arg.MoveID,
+	)
+	var remaining_pp int32
+	err := row.Scan(&remaining_pp)
+	return remaining_pp, err
 }
@@ -3,6 +3,7 @@ import WebSocket from 'ws';
 import { array, boolean, number, object, string } from 'yup';
 
 export const WS_URL = 'ws://localhost:3003/battle';
+export const API_URL = 'http://localhost:3003';
 
 // Message types matching your Go server
 export const CLIENT_MESSAGE_TYPE = {
@@ -12,11 +13,51 @@ export const CLIENT_MESSAGE_TYPE = {
   Surrender: 4,
   Status: 5,
   Match: 6,
+  Reconnect: 7,
+  CreateRoom: 8,
+  JoinRoom: 9,
+  CancelRoom: 10,
+  CancelMatch: 11,
+  PlayBot: 12,
+  ListBattles: 13,
+  Spectate: 14,
+  StopSpectate: 15,
 } as const;
 
-export const ATTACK_RESPONSE_SCHEMA = object().shape({
+export const MOVE_INFO_SCHEMA = object().shape({
+  move_id: number().required(),
+  name: string().required(),
+  pp: number().required(),
+  max_pp: number().required(),
+});
+
+const STAGES_SCHEMA = object().shape({
+  attack: number().integer().min(-6).max(6).required(),
+  defense: number().integer().min(-6).max(6).required(),
+  speed: number().integer().min(-6).max(6).required(),
+}).required();
+
+export const BATTLE_EVENT_SCHEMA = object().shape({
+  type: string().oneOf(["switch", "attack", "move", "miss", "recoil", "drain", "faint", "status", "status_damage", "cant_move", "cure", "stat"]).required(),
+  player_id: string().uuid().required(),
+  position: number().required(),
+  move_id: number().optional(),
+  move_name: string().optional(),
+  damage: number().optional(),
+  remaining_hp: number().required(),
+  effectiveness: string().oneOf(["no_effect", "not_very_effective", "normal", "super_effective"]).optional(),
+  critical_hit: boolean().optional(),
+  status: string().oneOf(["burn", "paralysis", "poison", "sleep", "freeze"]).optional(),
+  stat: string().oneOf(["attack", "defense", "speed"]).optional(),
+  stat_change: number().integer().optional(),
+  message: string().required(),
+});
+
+export const TURN_RESULT_SCHEMA = object().shape({
   battle_id: string().uuid().required(),
+  turn: number().required(),
   message: string().required(),
+  events: array().of(BATTLE_EVENT_SCHEMA).required(),
   your_info: object().shape({
     player_id: string().uuid().required(),
     username: string().required(),
@@ -25,7 +66,11 @@ export const ATTACK_RESPONSE_SCHEMA = object().shape({
       position: number().required(),
       current_hp: number().required(),
       is_fainted: boolean().required(),
-    })).required()
+      status: string().oneOf(["", "burn", "paralysis", "poison", "sleep", "freeze"]).defined(),
+      stages: STAGES_SCHEMA,
+      moves: array().of(MOVE_INFO_SCHEMA).optional(),
+    })).required(),
+    must_switch: boolean().required(),
   }).required(),
   opponent_info: object().shape({
     player_id: string().uuid().required(),
@@ -35,10 +80,90 @@ export const ATTACK_RESPONSE_SCHEMA = object().shape({
       position: number().required(),
       current_hp: number().required(),
       is_fainted: boolean().required(),
-    })).required()
+      status: string().oneOf(["", "burn", "paralysis", "poison", "sleep", "freeze"]).defined(),
+      stages: STAGES_SCHEMA,
+      moves: array().of(MOVE_INFO_SCHEMA).optional(),
+    })).required(),
+    must_switch: boolean().required(),
   }).required(),
   battle_ended: boolean().optional(),
   winner: string().uuid().optional(),
+  end_reason: string().oneOf(["all_fainted", "surrender", "disconnect", "timeout"]).optional(),
+})
+
+const PLAYER_INFO_SCHEMA = object().shape({
+  player_id: string().uuid().required(),
+  username: string().required(),
+  team: array().of(object().shape({
+    species_id: number().required(),
+    position: number().required(),
+    current_hp: number().required(),
+    is_fainted: boolean().required(),
+    status: string().oneOf(["", "burn", "paralysis", "poison", "sleep", "freeze"]).defined(),
+    stages: STAGES_SCHEMA,
+    moves: array().of(MOVE_INFO_SCHEMA).optional(),
+  })).required(),
+  must_switch: boolean().required(),
+}).required();
+
+export const SPECTATOR_STATE_SCHEMA = object().shape({
+  battle_id: string().uuid().required(),
+  turn: number().required(),
+  message: string().required(),
+  events: array().of(BATTLE_EVENT_SCHEMA).required(),
+  player1_info: PLAYER_INFO_SCHEMA,
+  player2_info: PLAYER_INFO_SCHEMA,
+  battle_ended: boolean().optional(),
+  winner: string().uuid().optional(),
+  end_reason: string().oneOf(["all_fainted", "surrender", "disconnect", "timeout"]).optional(),
+})
+
+export const BATTLE_LIST_SCHEMA = object().shape({
+  battles: array().of(object().shape({
+    battle_id: string().uuid().required(),
+    player1: string().required(),
+    player2: string().required(),
+    spectators: number().integer().min(0).required(),
+  })).required(),
+})
+
+export const SPECTATE_STOPPED_SCHEMA = object().shape({
+  battle_id: string().uuid().required(),
+})
+
+export const REPLAY_SCHEMA = object().shape({
+  battle_id: string().uuid().required(),
+  status: string().oneOf(["active", "completed", "abandoned"]).required(),
+  ranked: boolean().required(),
+  started_at: string().optional(),
+  ended_at: string().optional(),
+  turns: array().of(object().shape({
+    turn: number().integer().min(0).required(),
+    events: array().of(object().shape({
+      sequence: number().integer().positive().required(),
+      type: string().oneOf(["team", "action", "switch", "attack", "move", "miss", "recoil", "drain", "faint", "status", "status_damage", "cant_move", "cure", "stat", "end"]).required(),
+      player_id: string().uuid().optional(),
+      position: number().optional(),
+      species_id: number().optional(),
+      action_type: string().oneOf(["attack", "switch"]).optional(),
+      move_id: number().optional(),
+      damage: number().optional(),
+      remaining_hp: number().optional(),
+      effectiveness: string().optional(),
+      critical_hit: boolean().optional(),
+      status: string().optional(),
+      stat: string().optional(),
+      stat_change: number().integer().optional(),
+      end_reason: string().optional(),
+      message: string().optional(),
+    })).required(),
+  })).required(),
+})
+
+export const ACTION_QUEUED_SCHEMA = object().shape({
+  battle_id: string().uuid().required(),
+  turn: number().required(),
+  message: string().required(),
 })
 
 export const SERVER_MESSAGE_TYPE = {
@@ -51,8 +176,20 @@ export const SERVER_MESSAGE_TYPE = {
   Error: 56,
   MatchFound: 57,
   QueueJoined: 58,
+  TurnResult: 59,
+  TurnClock: 60,
+  RoomCreated: 61,
+  RoomClosed: 62,
+  QueueLeft: 63,
+  BattleList: 64,
+  SpectatorState: 65,
+  SpectateStopped: 66,
 } as const;
 
+// Move ids from the seed data (db/game/migrations/02-dummy-data.sql)
+export const TACKLE = 1;
+export const BODY_SLAM = 4;
+
 export interface Message<T = any> {
   type: number;
   payload: T;
@@ -72,12 +209,56 @@ export const CONNECT_REQUEST = (username: string, pokemons: number[]) => {
 export const CONNECT_SCHEMA = object().shape({
   id: string().uuid().required(),
   username: string().required(),
+  resume_token: string().required(),
 });
 
+export const RECONNECT_REQUEST = (resumeToken: string) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.Reconnect, {
+    resume_token: resumeToken,
+  });
+};
+
+// Matches RECONNECT_GRACE_SECONDS given to the server in process-compose.yaml
+export const RECONNECT_GRACE_MS = 5000;
+
+// Match TURN_TIMEOUT_SECONDS and TURN_TICK_SECONDS given to the server in process-compose.yaml
+export const TURN_TIMEOUT_MS = 20000;
+export const TURN_TICK_MS = 10000;
+
 export const MATCH_REQUEST = () => {
   return createMessage(CLIENT_MESSAGE_TYPE.Match, {});
 };
 
+export const CREATE_ROOM_REQUEST = () => {
+  return createMessage(CLIENT_MESSAGE_TYPE.CreateRoom, {});
+};
+
+export const JOIN_ROOM_REQUEST = (code: string) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.JoinRoom, { code: code });
+};
+
+export const CANCEL_ROOM_REQUEST = () => {
+  return createMessage(CLIENT_MESSAGE_TYPE.CancelRoom, {});
+};
+
+// Matches ROOM_TIMEOUT_SECONDS given to the server in process-compose.yaml
+export const ROOM_TIMEOUT_MS = 3000;
+
+export const CANCEL_MATCH_REQUEST = () => {
+  return createMessage(CLIENT_MESSAGE_TYPE.CancelMatch, {});
+};
+
+// Matches QUEUE_MAX_WAIT_SECONDS given to the server in process-compose.yaml
+export const QUEUE_MAX_WAIT_MS = 4000;
+
+export const RANKED_MATCH_REQUEST = () => {
+  return createMessage(CLIENT_MESSAGE_TYPE.Match, { ranked: true });
+};
+
+export const PLAY_BOT_REQUEST = (level?: string) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.PlayBot, level ? { level } : {});
+};
+
 export const MATCH_FOUND_SCHEMA = object().shape({
   battle_id: string().uuid().required(),
   your_info: object().shape({
@@ -88,6 +269,9 @@ export const MATCH_FOUND_SCHEMA = object().shape({
       position: number().required(),
       current_hp: number().required(),
       is_fainted: boolean().required(),
+      status: string().oneOf(["", "burn", "paralysis", "poison", "sleep", "freeze"]).defined(),
+      stages: STAGES_SCHEMA,
+      moves: array().of(MOVE_INFO_SCHEMA).optional(),
     })).required()
   }).required(),
   opponent_info: object().shape({
@@ -98,6 +282,9 @@ export const MATCH_FOUND_SCHEMA = object().shape({
       position: number().required(),
       current_hp: number().required(),
       is_fainted: boolean().required(),
+      status: string().oneOf(["", "burn", "paralysis", "poison", "sleep", "freeze"]).defined(),
+      stages: STAGES_SCHEMA,
+      moves: array().of(MOVE_INFO_SCHEMA).optional(),
     })).required()
   }).required()
 });
@@ -107,9 +294,24 @@ export const QUEUE_JOINED_SCHEMA = object().shape({
   queue_size: number().required(),
 });
 
-export const BATTLE_ENDED_SCHEMA = object().shape({
+export const QUEUE_LEFT_SCHEMA = object().shape({
   message: string().required(),
-  queue_size: number().required(),
+  reason: string().oneOf(["cancelled", "timeout"]).required(),
+});
+
+export const RANKED_QUEUE_JOINED_SCHEMA = QUEUE_JOINED_SCHEMA.shape({
+  ranked: boolean().required(),
+  rating: number().integer().required(),
+  search_window: object().shape({
+    min_rating: number().integer().required(),
+    max_rating: number().integer().required(),
+  }).required(),
+  estimated_wait_seconds: number().integer().min(0).required(),
+});
+
+export const BATTLE_ENDED_SCHEMA = TURN_RESULT_SCHEMA.shape({
+  battle_ended: boolean().required(),
+  winner: string().uuid().required(),
 })
 
 export const ATTACK_REQUEST = (battleId: string, moveId: number) => {
@@ -119,6 +321,58 @@ export const ATTACK_REQUEST = (battleId: string, moveId: number) => {
   });
 };
 
+export const CHANGE_POKEMON_REQUEST = (battleId: string, position: number) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.ChangePokemon, {
+    battle_id: battleId,
+    position: position,
+  });
+};
+
+export const SURRENDER_REQUEST = (battleId: string) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.Surrender, {
+    battle_id: battleId,
+  });
+};
+
+export const LIST_BATTLES_REQUEST = () => {
+  return createMessage(CLIENT_MESSAGE_TYPE.ListBattles, {});
+};
+
+export const SPECTATE_REQUEST = (battleId: string) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.Spectate, {
+    battle_id: battleId,
+  });
+};
+
+export const STOP_SPECTATE_REQUEST = (battleId: string) => {
+  return createMessage(CLIENT_MESSAGE_TYPE.StopSpectate, {
+    battle_id: battleId,
+  });
+};
+
+export const OPPONENT_DISCONNECTED_SCHEMA = object().shape({
+  battle_id: string().uuid().required(),
+  player_id: string().uuid().required(),
+  reconnected: boolean().required(),
+  forfeit_in: number().integer().positive().optional(),
+});
+
+export const TURN_CLOCK_SCHEMA = object().shape({
+  battle_id: string().uuid().required(),
+  turn: number().integer().positive().required(),
+  remaining_seconds: number().integer().positive().required(),
+});
+
+export const ROOM_CREATED_SCHEMA = object().shape({
+  code: string().length(6).required(),
+  expires_in_seconds: number().integer().positive().required(),
+});
+
+export const ROOM_CLOSED_SCHEMA = object().shape({
+  code: string().length(6).required(),
+  reason: string().oneOf(["cancelled", "expired"]).required(),
+});
+
 export const ERROR_SCHEMA = object().shape({
   msg: string().required(),
   code: number().required(),
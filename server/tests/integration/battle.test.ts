@@ -6,7 +6,18 @@ import {
   MATCH_REQUEST,
   MATCH_FOUND_SCHEMA,
   ATTACK_REQUEST,
-  ATTACK_RESPONSE_SCHEMA,
+  CHANGE_POKEMON_REQUEST,
+  TURN_RESULT_SCHEMA,
+  ACTION_QUEUED_SCHEMA,
+  BATTLE_ENDED_SCHEMA,
+  SURRENDER_REQUEST,
+  RECONNECT_GRACE_MS,
+  OPPONENT_DISCONNECTED_SCHEMA,
+  TURN_CLOCK_SCHEMA,
+  TURN_TIMEOUT_MS,
+  TURN_TICK_MS,
+  BODY_SLAM,
+  TACKLE,
   ERROR_SCHEMA,
   SERVER_MESSAGE_TYPE,
   validateResponse,
@@ -24,8 +35,9 @@ async function setupBattle() {
   await Promise.all([client1.connect(), client2.connect()]);
 
   // Connect both players
-  await client1.send(CONNECT_REQUEST("Player1", [1, 2, 3]));
-  await client2.send(CONNECT_REQUEST("Player2", [1, 5, 6]));
+  // Every pokemon on both teams knows Body Slam
+  await client1.send(CONNECT_REQUEST("Player1", [1, 2, 7]));
+  await client2.send(CONNECT_REQUEST("Player2", [2, 10, 7]));
 
   await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
 
@@ -48,89 +60,187 @@ async function setupBattle() {
   return { client1, client2, battleId };
 }
 
-describe("Battle System", () => {
+// Helper function to play a full turn where both players attack.
+// Player1 chooses first and gets an acknowledgement, the turn is
+// resolved once player2 chooses.
+async function playTurn(
+  client1: WSTestClient,
+  client2: WSTestClient,
+  battleId: string
+): Promise<[Message, Message]> {
+  await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+  const ack = await waitForMessage(client1);
+  expect(ack.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+
+  await client2.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+  const [response1, response2] = await Promise.all([
+    waitForMessage(client1),
+    waitForMessage(client2),
+  ]);
 
-  test("should allow player1 to attack on turn 1", async () => {
-    const { client1, client2, battleId } = await setupBattle();
+  expect(response1.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+  expect(response2.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
 
-    // Player1 attacks (turn 1 = player1's turn)
-    await client1.send(ATTACK_REQUEST(battleId, 1));
+  return [response1, response2];
+}
 
-    const [response1, response2] = await Promise.all([
+// Helper function to send out a replacement for every fainted active pokemon,
+// which has to happen before the next turn. Returns the last TurnResult of each player.
+async function replaceFainted(
+  client1: WSTestClient,
+  client2: WSTestClient,
+  battleId: string,
+  responses: [Message, Message]
+): Promise<[Message, Message]> {
+  const clients = [client1, client2];
+  for (let i = 0; i < clients.length; i++) {
+    const info = responses[i].payload.your_info;
+    if (!info.must_switch) continue;
+
+    const replacement = info.team.find(
+      (p) => !p.is_fainted && p.position !== info.active_pokemon
+    );
+    await clients[i].send(CHANGE_POKEMON_REQUEST(battleId, replacement.position));
+    responses = await Promise.all([
       waitForMessage(client1),
       waitForMessage(client2),
     ]);
+    expect(responses[0].type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+    expect(responses[1].type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+  }
+  return responses;
+}
+
+describe("Battle System", () => {
+
+  test("should wait for the opponent before resolving the turn", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const ack = await waitForMessage(client1);
+
+    expect(ack.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+    validateResponse(ack.payload, ACTION_QUEUED_SCHEMA);
+    expect(ack.payload.turn).toBe(1);
+    expect(ack.payload.message).toContain("Waiting");
+
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should resolve the turn once both players chose an action", async () => {
+    const { client1, client2, battleId } = await setupBattle();
 
-    // Both players should receive attack response
-    expect(response1.type).toBe(SERVER_MESSAGE_TYPE.Attack);
-    expect(response2.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+    const [response1, response2] = await playTurn(client1, client2, battleId);
+
+    validateResponse(response1.payload, TURN_RESULT_SCHEMA);
+    validateResponse(response2.payload, TURN_RESULT_SCHEMA);
 
-    validateResponse(response1.payload, ATTACK_RESPONSE_SCHEMA);
-    validateResponse(response2.payload, ATTACK_RESPONSE_SCHEMA);
-    
     console.log(JSON.stringify(response1.payload, null, "\t"))
 
-    // Verify damage was dealt
+    // Both attacks are reported, in the same order for both players
+    const attacks = response1.payload.events.filter((e) => e.type === "attack");
+    expect(attacks.length).toBe(2);
+    expect(response1.payload.events).toEqual(response2.payload.events);
     expect(response1.payload.message).toContain("damage");
-    expect(response2.payload.message).toContain("damage");
 
     await Promise.all([client1.close(), client2.close()]);
   });
 
-  test("should reject attack from wrong player on turn 1", async () => {
+  test("should let the faster pokemon move first", async () => {
     const { client1, client2, battleId } = await setupBattle();
 
-    // Player2 tries to attack on turn 1 (should fail - it's player1's turn)
-    await client2.send(ATTACK_REQUEST(battleId, 1));
-
-    const errorResponse = await waitForMessage(client2);
+    // Charizard (speed 100) outspeeds Blastoise (speed 78)
+    const [response1] = await playTurn(client1, client2, battleId);
 
-    expect(errorResponse.type).toBe(SERVER_MESSAGE_TYPE.Error);
-    validateResponse(errorResponse.payload, ERROR_SCHEMA);
-    expect(errorResponse.payload.msg).toContain("Bad request");
-    expect(errorResponse.payload.details.error).toContain("not your turn");
+    const firstEvent = response1.payload.events[0];
+    expect(firstEvent.type).toBe("attack");
+    expect(firstEvent.player_id).toBe(response1.payload.your_info.player_id);
 
     await Promise.all([client1.close(), client2.close()]);
   });
 
-  test("should alternate turns between players", async () => {
+  test("should switch before any move is used", async () => {
     const { client1, client2, battleId } = await setupBattle();
 
-    // Turn 1: Player1 attacks
-    await client1.send(ATTACK_REQUEST(battleId, 1));
-    const [turn1_p1, turn1_p2] = await Promise.all([
-      waitForMessage(client1),
-      waitForMessage(client2),
-    ]);
-    expect(turn1_p1.type).toBe(SERVER_MESSAGE_TYPE.Attack);
-    expect(turn1_p2.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+    await client1.send(CHANGE_POKEMON_REQUEST(battleId, 2));
+    const ack = await waitForMessage(client1);
+    expect(ack.type).toBe(SERVER_MESSAGE_TYPE.ChangePokemon);
 
-    // Turn 2: Player2 attacks
-    await client2.send(ATTACK_REQUEST(battleId, 1));
-    const [turn2_p1, turn2_p2] = await Promise.all([
+    await client2.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const [response1] = await Promise.all([
       waitForMessage(client1),
       waitForMessage(client2),
     ]);
-    expect(turn2_p1.type).toBe(SERVER_MESSAGE_TYPE.Attack);
-    expect(turn2_p2.type).toBe(SERVER_MESSAGE_TYPE.Attack);
 
-    // Turn 3: Player1 attacks again
-    await client1.send(ATTACK_REQUEST(battleId, 1));
-    const [turn3_p1, turn3_p2] = await Promise.all([
-      waitForMessage(client1),
-      waitForMessage(client2),
-    ]);
-    expect(turn3_p1.type).toBe(SERVER_MESSAGE_TYPE.Attack);
-    expect(turn3_p2.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+    expect(response1.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+    expect(response1.payload.events[0].type).toBe("switch");
+    expect(response1.payload.your_info.active_pokemon).toBe(2);
+
+    // The attack hits the pokemon that switched in
+    const charizard = response1.payload.your_info.team.find((p) => p.position === 1);
+    const blastoise = response1.payload.your_info.team.find((p) => p.position === 2);
+    expect(charizard.current_hp).toBe(78);
+    expect(blastoise.current_hp).toBeLessThan(79);
 
-    // Turn 4: Player2 attacks again
-    await client2.send(ATTACK_REQUEST(battleId, 1));
-    const [turn4_p1, turn4_p2] = await Promise.all([
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should start every battle with the team at full health", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    const [response1] = await playTurn(client1, client2, battleId);
+    expect(response1.payload.opponent_info.team[0].current_hp).toBeLessThan(79);
+
+    // Damage taken in one battle belongs to that battle only
+    await client1.send(MATCH_REQUEST());
+    await waitForMessage(client1); // Queue joined
+    await client2.send(MATCH_REQUEST());
+
+    const [match1, match2] = await Promise.all([
       waitForMessage(client1),
       waitForMessage(client2),
     ]);
-    expect(turn4_p1.type).toBe(SERVER_MESSAGE_TYPE.Attack);
-    expect(turn4_p2.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+    expect(match1.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+    expect(match1.payload.battle_id).not.toBe(battleId);
+
+    for (const match of [match1, match2]) {
+      for (const team of [match.payload.your_info.team, match.payload.opponent_info.team]) {
+        for (const pokemon of team) {
+          expect(pokemon.is_fainted).toBe(false);
+          expect(pokemon.moves.every((m) => m.pp === m.max_pp)).toBe(true);
+          expect(pokemon.status).toBe("");
+          expect(pokemon.stages).toEqual({ attack: 0, defense: 0, speed: 0 });
+        }
+      }
+    }
+    expect(match2.payload.your_info.team[0].current_hp).toBe(79);
+
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should reject a move the active pokemon does not know", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    // Charizard does not learn Tackle
+    await client1.send(ATTACK_REQUEST(battleId, TACKLE));
+
+    const errorResponse = await waitForMessage(client1);
+
+    expect(errorResponse.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(errorResponse.payload, ERROR_SCHEMA);
+    expect(errorResponse.payload.details.error).toContain("not known");
+
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should spend one PP of the used move", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    const [response1] = await playTurn(client1, client2, battleId);
+
+    const attacker = response1.payload.your_info.team.find((p) => p.position === 1);
+    const bodySlam = attacker.moves.find((m) => m.move_id === BODY_SLAM);
+    expect(bodySlam.pp).toBe(bodySlam.max_pp - 1);
 
     await Promise.all([client1.close(), client2.close()]);
   });
@@ -138,12 +248,7 @@ describe("Battle System", () => {
   test("should damage defender's active pokemon", async () => {
     const { client1, client2, battleId } = await setupBattle();
 
-    // Get initial HP of player2's first pokemon (active by default)
-    await client1.send(ATTACK_REQUEST(battleId, 1));
-    const [response1, response2] = await Promise.all([
-      waitForMessage(client1),
-      waitForMessage(client2),
-    ]);
+    const [, response2] = await playTurn(client1, client2, battleId);
 
     const initialHP =
       response2.payload.your_info.team.find((p) => p.position === 1)
@@ -151,15 +256,7 @@ describe("Battle System", () => {
 
     console.log(JSON.stringify(response2.payload, null, "\t"))
 
-    // Attack again on player1's next turn
-    await client2.send(ATTACK_REQUEST(battleId, 1)); // Player2 turn 2
-    await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
-
-    await client1.send(ATTACK_REQUEST(battleId, 1)); // Player1 turn 3
-    const [response3, response4] = await Promise.all([
-      waitForMessage(client1),
-      waitForMessage(client2),
-    ]);
+    const [, response4] = await playTurn(client1, client2, battleId);
 
     const newHP =
       response4.payload.your_info.team.find((p) => p.position === 1)
@@ -173,50 +270,60 @@ describe("Battle System", () => {
     await Promise.all([client1.close(), client2.close()]);
   });
 
-  test("should auto-switch to next pokemon when active pokemon faints", async () => {
+  test("should let the player choose a replacement when the active pokemon faints", async () => {
     const { client1, client2, battleId } = await setupBattle();
 
-    // Attack repeatedly until first pokemon faints
-    let lastResponse1: Message;
-    let lastResponse2: Message;
     let turn = 1;
     const maxTurns = 20; // Safety limit
 
     while (turn <= maxTurns) {
-      if (turn % 2 === 1) {
-        // Player1's turn
-        await client1.send(ATTACK_REQUEST(battleId, 1));
-      } else {
-        // Player2's turn
-        await client2.send(ATTACK_REQUEST(battleId, 1));
+      const responses = await playTurn(client1, client2, battleId);
+
+      // Check if an active pokemon fainted
+      const fainted = responses.findIndex((r) => r.payload.your_info.must_switch);
+      if (fainted === -1) {
+        turn++;
+        continue;
       }
 
-      [lastResponse1, lastResponse2] = await Promise.all([
+      const clients = [client1, client2];
+      const chooser = clients[fainted];
+      const waiting = clients[1 - fainted];
+      const info = responses[fainted].payload.your_info;
+
+      // The fainted pokemon stays active until its player chooses, both players know it
+      const active = info.team.find((p) => p.position === info.active_pokemon);
+      expect(active.current_hp).toBe(0);
+      expect(active.is_fainted).toBe(true);
+      expect(responses[1 - fainted].payload.opponent_info.must_switch).toBe(true);
+
+      // Nobody can choose an action for the next turn until then
+      await waiting.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+      const error = await waitForMessage(waiting);
+      expect(error.type).toBe(SERVER_MESSAGE_TYPE.Error);
+      expect(error.payload.details.error).toContain("fainted");
+
+      // Send out the last pokemon standing rather than the next one
+      const replacement = info.team
+        .filter((p) => !p.is_fainted && p.position !== info.active_pokemon)
+        .at(-1);
+      await chooser.send(CHANGE_POKEMON_REQUEST(battleId, replacement.position));
+
+      const switched = await Promise.all([
         waitForMessage(client1),
         waitForMessage(client2),
       ]);
+      expect(switched[0].type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+      expect(switched[1].type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+      validateResponse(switched[fainted].payload, TURN_RESULT_SCHEMA);
 
-      // Check if first pokemon fainted
-      const player2Pokemon1 = lastResponse2.payload.your_info.team.find(
-        (p) => p.position === 1
-      );
-
-      if (player2Pokemon1 && player2Pokemon1.is_fainted) {
-        // Pokemon fainted! Check if auto-switch happened
-        expect(player2Pokemon1.current_hp).toBe(0);
-        expect(player2Pokemon1.is_fainted).toBe(true);
-
-        // Should have switched to next available pokemon (position 2)
-        const player2Pokemon2 = lastResponse2.payload.your_info.team.find(
-          (p) => p.position === 2
-        );
-        expect(player2Pokemon2).toBeDefined();
-        expect(player2Pokemon2.current_hp).toBeGreaterThan(0);
-
-        break;
-      }
+      // The switch does not use up the next turn
+      expect(switched[fainted].payload.turn).toBe(turn + 1);
+      expect(switched[fainted].payload.events[0].type).toBe("switch");
+      expect(switched[fainted].payload.your_info.active_pokemon).toBe(replacement.position);
+      expect(switched[fainted].payload.your_info.must_switch).toBe(false);
 
-      turn++;
+      break;
     }
 
     expect(turn).toBeLessThan(maxTurns); // Ensure we didn't timeout
@@ -232,18 +339,8 @@ describe("Battle System", () => {
     let battleEnded = false;
 
     while (turn <= maxTurns && !battleEnded) {
-      if (turn % 2 === 1) {
-        // Player1's turn
-        await client1.send(ATTACK_REQUEST(battleId, 1));
-      } else {
-        // Player2's turn
-        await client2.send(ATTACK_REQUEST(battleId, 1));
-      }
-
-      const [response1, response2] = await Promise.all([
-        waitForMessage(client1),
-        waitForMessage(client2),
-      ]);
+      const responses = await playTurn(client1, client2, battleId);
+      const [response1, response2] = responses;
 
       if (response1.payload.battle_ended) {
         battleEnded = true;
@@ -277,6 +374,17 @@ describe("Battle System", () => {
             response1.payload.your_info.player_id
           );
         }
+
+        // Both players are told the battle ended
+        const [ended1, ended2] = await Promise.all([
+          waitForMessage(client1),
+          waitForMessage(client2),
+        ]);
+        expect(ended1.type).toBe(SERVER_MESSAGE_TYPE.BattleEnded);
+        expect(ended2.type).toBe(SERVER_MESSAGE_TYPE.BattleEnded);
+        expect(ended1.payload.end_reason).toBe("all_fainted");
+      } else {
+        await replaceFainted(client1, client2, battleId, responses);
       }
 
       turn++;
@@ -287,30 +395,22 @@ describe("Battle System", () => {
     await Promise.all([client1.close(), client2.close()]);
   });
 
-  test("should handle rapid attack requests from same player", async () => {
+  test("should reject a second action in the same turn", async () => {
     const { client1, client2, battleId } = await setupBattle();
 
     // Player1 sends multiple attacks in quick succession
-    await client1.send(ATTACK_REQUEST(battleId, 1));
-    await client1.send(ATTACK_REQUEST(battleId, 1)); // Should fail - not their turn after first
-    await client1.send(ATTACK_REQUEST(battleId, 1)); // Should fail - not their turn
-
-    // First attack should succeed
-    const [success1_p1, success1_p2] = await Promise.all([
-      waitForMessage(client1),
-      waitForMessage(client2),
-    ]);
-    expect(success1_p1.type).toBe(SERVER_MESSAGE_TYPE.Attack);
-    expect(success1_p2.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+    await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM)); // Should fail - already chosen
 
-    // Next two should be errors (not player1's turn anymore)
-    const error1 = await waitForMessage(client1);
-    expect(error1.type).toBe(SERVER_MESSAGE_TYPE.Error);
-    expect(error1.payload.details.error).toContain("not your turn");
+    // First attack is queued
+    const ack = await waitForMessage(client1);
+    expect(ack.type).toBe(SERVER_MESSAGE_TYPE.Attack);
 
-    const error2 = await waitForMessage(client1);
-    expect(error2.type).toBe(SERVER_MESSAGE_TYPE.Error);
-    expect(error2.payload.details.error).toContain("not your turn");
+    // The second one is rejected
+    const error = await waitForMessage(client1);
+    expect(error.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    expect(error.payload.msg).toContain("Bad request");
+    expect(error.payload.details.error).toContain("already submitted");
 
     await Promise.all([client1.close(), client2.close()]);
   });
@@ -319,20 +419,13 @@ describe("Battle System", () => {
     const { client1, client2, battleId } = await setupBattle();
 
     // Execute several turns
-    for (let i = 0; i < 4; i++) {
-      if (i % 2 === 0) {
-        await client1.send(ATTACK_REQUEST(battleId, 1));
-      } else {
-        await client2.send(ATTACK_REQUEST(battleId, 1));
-      }
-
-      const [response1, response2] = await Promise.all([
-        waitForMessage(client1),
-        waitForMessage(client2),
-      ]);
+    for (let i = 0; i < 3; i++) {
+      const [response1, response2] = await playTurn(client1, client2, battleId);
 
       // Both players should see the same battle state (from their perspective)
       expect(response1.payload.battle_id).toBe(response2.payload.battle_id);
+      expect(response1.payload.turn).toBe(i + 1);
+      expect(response2.payload.turn).toBe(i + 1);
 
       // Player1's your_info should match Player2's opponent_info
       expect(response1.payload.your_info.player_id).toBe(
@@ -349,8 +442,192 @@ describe("Battle System", () => {
       expect(response1.payload.opponent_info.team).toEqual(
         response2.payload.your_info.team
       );
+
+      if (response1.payload.battle_ended) break;
     }
 
     await Promise.all([client1.close(), client2.close()]);
   });
+
+  test("should end the battle when a player surrenders", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    await client1.send(SURRENDER_REQUEST(battleId));
+
+    const [ended1, ended2] = await Promise.all([
+      waitForMessage(client1),
+      waitForMessage(client2),
+    ]);
+
+    expect(ended1.type).toBe(SERVER_MESSAGE_TYPE.BattleEnded);
+    expect(ended2.type).toBe(SERVER_MESSAGE_TYPE.BattleEnded);
+    validateResponse(ended1.payload, BATTLE_ENDED_SCHEMA);
+    validateResponse(ended2.payload, BATTLE_ENDED_SCHEMA);
+
+    // The opponent of the surrendering player wins
+    expect(ended1.payload.end_reason).toBe("surrender");
+    expect(ended1.payload.winner).toBe(ended1.payload.opponent_info.player_id);
+    expect(ended2.payload.winner).toBe(ended2.payload.your_info.player_id);
+
+    // No more actions are accepted
+    await client2.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const errorResponse = await waitForMessage(client2);
+    expect(errorResponse.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    expect(errorResponse.payload.details.error).toContain("not active");
+
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should reject a surrender from a player outside the battle", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    const outsider = new WSTestClient(WS_URL);
+    await outsider.connect();
+    await outsider.send(CONNECT_REQUEST("Outsider", [1, 2, 3]));
+    await waitForMessage(outsider);
+
+    await outsider.send(SURRENDER_REQUEST(battleId));
+    const errorResponse = await waitForMessage(outsider);
+
+    expect(errorResponse.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(errorResponse.payload, ERROR_SCHEMA);
+    expect(errorResponse.payload.details.error).toContain("not part of this battle");
+
+    // The battle goes on
+    await playTurn(client1, client2, battleId);
+
+    await Promise.all([client1.close(), client2.close(), outsider.close()]);
+  });
+
+  test("should reject an action from a player outside the battle", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    const outsider = new WSTestClient(WS_URL);
+    await outsider.connect();
+    await outsider.send(CONNECT_REQUEST("Outsider", [1, 2, 3]));
+    await waitForMessage(outsider);
+
+    await outsider.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const errorResponse = await waitForMessage(outsider);
+
+    expect(errorResponse.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(errorResponse.payload, ERROR_SCHEMA);
+    expect(errorResponse.payload.details.error).toContain("not part of this battle");
+
+    await Promise.all([client1.close(), client2.close(), outsider.close()]);
+  });
+
+  test("should reject an action in an unknown battle", async () => {
+    const { client1, client2 } = await setupBattle();
+
+    await client1.send(ATTACK_REQUEST("00000000-0000-4000-8000-000000000000", BODY_SLAM));
+    const errorResponse = await waitForMessage(client1);
+
+    expect(errorResponse.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    expect(errorResponse.payload.details.error).toContain("battle not found");
+
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should only notify the players of the battle", async () => {
+    const first = await setupBattle();
+    const second = await setupBattle();
+
+    const [response1, response2] = await playTurn(first.client1, first.client2, first.battleId);
+    expect(response1.payload.battle_id).toBe(first.battleId);
+    expect(response1.payload.opponent_info.player_id).toBe(
+      response2.payload.your_info.player_id
+    );
+
+    // Players of the other battle received nothing and can still play their own turn
+    const [response3] = await playTurn(second.client1, second.client2, second.battleId);
+    expect(response3.payload.battle_id).toBe(second.battleId);
+    expect(response3.payload.turn).toBe(1);
+
+    await Promise.all([
+      first.client1.close(),
+      first.client2.close(),
+      second.client1.close(),
+      second.client2.close(),
+    ]);
+  });
+
+  test("should give the win to the opponent of a player that disconnects", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    await client1.close();
+
+    // The battle is held while player1 may still reconnect
+    const disconnected = await waitForMessage(client2);
+    expect(disconnected.type).toBe(SERVER_MESSAGE_TYPE.Disconnect);
+    validateResponse(disconnected.payload, OPPONENT_DISCONNECTED_SCHEMA);
+    expect(disconnected.payload.battle_id).toBe(battleId);
+    expect(disconnected.payload.reconnected).toBe(false);
+    expect(disconnected.payload.forfeit_in).toBe(RECONNECT_GRACE_MS / 1000);
+
+    const ended = await waitForMessage(client2, RECONNECT_GRACE_MS + 3000);
+    expect(ended.type).toBe(SERVER_MESSAGE_TYPE.BattleEnded);
+    validateResponse(ended.payload, BATTLE_ENDED_SCHEMA);
+    expect(ended.payload.battle_id).toBe(battleId);
+    expect(ended.payload.end_reason).toBe("disconnect");
+    expect(ended.payload.winner).toBe(ended.payload.your_info.player_id);
+
+    await client2.close();
+  }, RECONNECT_GRACE_MS + 5000);
+
+  test("should tell both players the time left to choose", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    const [tick1, tick2] = await Promise.all([
+      waitForMessage(client1, TURN_TICK_MS + 2000),
+      waitForMessage(client2, TURN_TICK_MS + 2000),
+    ]);
+
+    for (const tick of [tick1, tick2]) {
+      expect(tick.type).toBe(SERVER_MESSAGE_TYPE.TurnClock);
+      validateResponse(tick.payload, TURN_CLOCK_SCHEMA);
+      expect(tick.payload.battle_id).toBe(battleId);
+      expect(tick.payload.turn).toBe(1);
+      expect(tick.payload.remaining_seconds).toBe((TURN_TIMEOUT_MS - TURN_TICK_MS) / 1000);
+    }
+
+    await Promise.all([client1.close(), client2.close()]);
+  }, TURN_TICK_MS + 5000);
+
+  test("should choose a move for a player that runs out of time", async () => {
+    const { client1, client2, battleId } = await setupBattle();
+
+    // Only player1 chooses
+    await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const ack = await waitForMessage(client1);
+    expect(ack.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+
+    // Ticks come first, the turn is resolved once the clock runs out
+    const nextTurnResult = async (client: WSTestClient) => {
+      let msg = await waitForMessage(client, TURN_TIMEOUT_MS + 2000);
+      while (msg.type === SERVER_MESSAGE_TYPE.TurnClock) {
+        msg = await waitForMessage(client, TURN_TIMEOUT_MS + 2000);
+      }
+      return msg;
+    };
+    const [result1, result2] = await Promise.all([
+      nextTurnResult(client1),
+      nextTurnResult(client2),
+    ]);
+
+    expect(result1.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+    expect(result2.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+    validateResponse(result2.payload, TURN_RESULT_SCHEMA);
+    expect(result2.payload.turn).toBe(1);
+
+    // Player2 used their first move
+    const attacks = result2.payload.events.filter(
+      (event) => event.type === "attack" || event.type === "miss"
+    );
+    expect(attacks.map((event) => event.player_id)).toContain(
+      result2.payload.your_info.player_id
+    );
+
+    await Promise.all([client1.close(), client2.close()]);
+  }, TURN_TIMEOUT_MS + 5000);
 });
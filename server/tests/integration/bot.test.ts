@@ -0,0 +1,84 @@
+import { describe, test, expect } from "vitest";
+import {
+  CONNECT_REQUEST,
+  PLAY_BOT_REQUEST,
+  ATTACK_REQUEST,
+  MATCH_FOUND_SCHEMA,
+  TURN_RESULT_SCHEMA,
+  ERROR_SCHEMA,
+  SERVER_MESSAGE_TYPE,
+  BODY_SLAM,
+  validateResponse,
+  waitForMessage,
+  WS_URL,
+  WSTestClient,
+} from "../helpers";
+
+// Helper function to connect a single player
+async function connectPlayer() {
+  const client = new WSTestClient(WS_URL);
+  await client.connect();
+
+  // Machamp, Charizard and Blastoise all know Body Slam
+  await client.send(CONNECT_REQUEST("Human", [7, 1, 2]));
+  await waitForMessage(client);
+
+  return client;
+}
+
+// Skips acknowledgements and clock ticks until the turn is resolved
+async function waitForTurnResult(client: WSTestClient) {
+  while (true) {
+    const message = await waitForMessage(client);
+    if (message.type === SERVER_MESSAGE_TYPE.TurnResult) {
+      return message;
+    }
+  }
+}
+
+describe("Bot Battles", () => {
+
+  test("should start a battle against a bot right away", async () => {
+    const client = await connectPlayer();
+
+    await client.send(PLAY_BOT_REQUEST("random"));
+    const match = await waitForMessage(client);
+
+    expect(match.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+    validateResponse(match.payload, MATCH_FOUND_SCHEMA);
+    expect(match.payload.opponent_info.username).toBe("Bot (random)");
+
+    await client.close();
+  });
+
+  test.each(["greedy", "lookahead"])("should play turns against a %s bot", async (level) => {
+    const client = await connectPlayer();
+
+    await client.send(PLAY_BOT_REQUEST(level));
+    const match = await waitForMessage(client);
+    const battleId = match.payload.battle_id;
+
+    for (let turn = 1; turn <= 2; turn++) {
+      await client.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+      const result = await waitForTurnResult(client);
+
+      validateResponse(result.payload, TURN_RESULT_SCHEMA);
+      expect(result.payload.turn).toBe(turn);
+      expect(result.payload.opponent_info.username).toBe(`Bot (${level})`);
+    }
+
+    await client.close();
+  });
+
+  test("should reject an unknown level", async () => {
+    const client = await connectPlayer();
+
+    await client.send(PLAY_BOT_REQUEST("cheater"));
+    const response = await waitForMessage(client);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(response.payload, ERROR_SCHEMA);
+
+    await client.close();
+  });
+});
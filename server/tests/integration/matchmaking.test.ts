@@ -6,6 +6,13 @@ import {
   MATCH_REQUEST,
   MATCH_FOUND_SCHEMA,
   QUEUE_JOINED_SCHEMA,
+  RANKED_MATCH_REQUEST,
+  RANKED_QUEUE_JOINED_SCHEMA,
+  SURRENDER_REQUEST,
+  CANCEL_MATCH_REQUEST,
+  QUEUE_LEFT_SCHEMA,
+  QUEUE_MAX_WAIT_MS,
+  ERROR_SCHEMA,
   SERVER_MESSAGE_TYPE,
   validateResponse,
   waitForMessage,
@@ -222,4 +229,134 @@ describe("Matchmaking System", () => {
 
     await Promise.all(clients.map((c) => c.close()));
   });
-});
\ No newline at end of file
+
+  test("should report the search window and estimated wait in the ranked queue", async () => {
+    const client = new WSTestClient(WS_URL);
+    await client.connect();
+
+    // A new username has the initial rating
+    await client.send(CONNECT_REQUEST(`Ranked-${Date.now()}`, [1, 2, 3]));
+    await waitForMessage(client);
+
+    await client.send(RANKED_MATCH_REQUEST());
+    const queueResponse = await waitForMessage(client);
+
+    expect(queueResponse.type).toBe(SERVER_MESSAGE_TYPE.QueueJoined);
+    validateResponse(queueResponse.payload, RANKED_QUEUE_JOINED_SCHEMA);
+    expect(queueResponse.payload.ranked).toBe(true);
+    expect(queueResponse.payload.rating).toBe(1500);
+    expect(queueResponse.payload.search_window.min_rating).toBe(1400);
+    expect(queueResponse.payload.search_window.max_rating).toBe(1600);
+
+    await client.close();
+  });
+
+  test("should update the ratings of both players after a ranked battle", async () => {
+    const client1 = new WSTestClient(WS_URL);
+    const client2 = new WSTestClient(WS_URL);
+    await Promise.all([client1.connect(), client2.connect()]);
+
+    const suffix = Date.now();
+    await client1.send(CONNECT_REQUEST(`Winner-${suffix}`, [1, 2, 3]));
+    await client2.send(CONNECT_REQUEST(`Loser-${suffix}`, [4, 5, 6]));
+    await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+
+    await client1.send(RANKED_MATCH_REQUEST());
+    await waitForMessage(client1); // Queue joined
+
+    // Same rating, matched right away
+    await client2.send(RANKED_MATCH_REQUEST());
+    const [match1, match2] = await Promise.all([
+      waitForMessage(client1),
+      waitForMessage(client2),
+    ]);
+    expect(match1.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+    expect(match2.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+    expect(match1.payload.ranked).toBe(true);
+
+    await client2.send(SURRENDER_REQUEST(match2.payload.battle_id));
+    await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+
+    // Equal ratings move half of the K factor
+    await client1.send(RANKED_MATCH_REQUEST());
+    const winnerQueue = await waitForMessage(client1);
+    expect(winnerQueue.payload.rating).toBe(1516);
+    await client1.close();
+
+    await client2.send(RANKED_MATCH_REQUEST());
+    const loserQueue = await waitForMessage(client2);
+    expect(loserQueue.payload.rating).toBe(1484);
+    await client2.close();
+  });
+
+  test("should let a player leave the queue", async () => {
+    const client1 = new WSTestClient(WS_URL);
+    const client2 = new WSTestClient(WS_URL);
+    await Promise.all([client1.connect(), client2.connect()]);
+
+    await client1.send(CONNECT_REQUEST("Player1", [1, 2, 3]));
+    await client2.send(CONNECT_REQUEST("Player2", [4, 5, 6]));
+    await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+
+    await client1.send(MATCH_REQUEST());
+    await waitForMessage(client1); // Queue joined
+
+    await client1.send(CANCEL_MATCH_REQUEST());
+    const left = await waitForMessage(client1);
+    expect(left.type).toBe(SERVER_MESSAGE_TYPE.QueueLeft);
+    validateResponse(left.payload, QUEUE_LEFT_SCHEMA);
+    expect(left.payload.reason).toBe("cancelled");
+
+    // Player1 is no longer matched
+    await client2.send(MATCH_REQUEST());
+    const queued = await waitForMessage(client2);
+    expect(queued.type).toBe(SERVER_MESSAGE_TYPE.QueueJoined);
+    expect(queued.payload.queue_size).toBe(1);
+
+    // Leaving twice fails
+    await client1.send(CANCEL_MATCH_REQUEST());
+    const error = await waitForMessage(client1);
+    expect(error.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(error.payload, ERROR_SCHEMA);
+
+    await Promise.all([client1.close(), client2.close()]);
+  });
+
+  test("should not enqueue the same player twice", async () => {
+    const client = new WSTestClient(WS_URL);
+    await client.connect();
+
+    await client.send(CONNECT_REQUEST("Player1", [1, 2, 3]));
+    await waitForMessage(client);
+
+    await client.send(MATCH_REQUEST());
+    const queued = await waitForMessage(client);
+    expect(queued.type).toBe(SERVER_MESSAGE_TYPE.QueueJoined);
+
+    // A second request must not match the player against themselves
+    await client.send(MATCH_REQUEST());
+    const response = await waitForMessage(client);
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    expect(response.payload.details.error).toContain("already in matchmaking queue");
+
+    await client.close();
+  });
+
+  test("should remove a player that waits too long", async () => {
+    const client = new WSTestClient(WS_URL);
+    await client.connect();
+
+    await client.send(CONNECT_REQUEST("Player1", [1, 2, 3]));
+    await waitForMessage(client);
+
+    await client.send(MATCH_REQUEST());
+    await waitForMessage(client); // Queue joined
+
+    const left = await waitForMessage(client, QUEUE_MAX_WAIT_MS + 3000);
+    expect(left.type).toBe(SERVER_MESSAGE_TYPE.QueueLeft);
+    validateResponse(left.payload, QUEUE_LEFT_SCHEMA);
+    expect(left.payload.reason).toBe("timeout");
+
+    await client.close();
+  }, QUEUE_MAX_WAIT_MS + 5000);
+});
@@ -0,0 +1,160 @@
+import { describe, test, expect } from "vitest";
+import {
+  CONNECT_REQUEST,
+  CONNECT_SCHEMA,
+  MATCH_REQUEST,
+  ATTACK_REQUEST,
+  RECONNECT_REQUEST,
+  RECONNECT_GRACE_MS,
+  TURN_RESULT_SCHEMA,
+  BODY_SLAM,
+  ERROR_SCHEMA,
+  OPPONENT_DISCONNECTED_SCHEMA,
+  SERVER_MESSAGE_TYPE,
+  validateResponse,
+  waitForMessage,
+  WS_URL,
+  WSTestClient,
+} from "../helpers";
+
+// Helper function to setup a battle, keeping the session data of both players
+async function setupBattle() {
+  const client1 = new WSTestClient(WS_URL);
+  const client2 = new WSTestClient(WS_URL);
+
+  await Promise.all([client1.connect(), client2.connect()]);
+
+  await client1.send(CONNECT_REQUEST("Player1", [1, 2, 7]));
+  await client2.send(CONNECT_REQUEST("Player2", [2, 10, 7]));
+
+  const [session1, session2] = await Promise.all([
+    waitForMessage(client1),
+    waitForMessage(client2),
+  ]);
+  validateResponse(session1.payload, CONNECT_SCHEMA);
+
+  await client1.send(MATCH_REQUEST());
+  await waitForMessage(client1); // Queue joined
+
+  await client2.send(MATCH_REQUEST());
+
+  const [match1] = await Promise.all([
+    waitForMessage(client1),
+    waitForMessage(client2),
+  ]);
+  expect(match1.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+
+  return {
+    client1,
+    client2,
+    session1: session1.payload,
+    session2: session2.payload,
+    battleId: match1.payload.battle_id,
+  };
+}
+
+describe("Reconnection", () => {
+
+  test("should give a connected player a resume token", async () => {
+    const client = new WSTestClient(WS_URL);
+    await client.connect();
+
+    await client.send(CONNECT_REQUEST("persona 1", [1, 2, 3]));
+    const response = await waitForMessage(client);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.AcceptConnection);
+    validateResponse(response.payload, CONNECT_SCHEMA);
+    expect(response.payload.resume_token.length).toBeGreaterThan(0);
+
+    await client.close();
+  });
+
+  test("should resume the session and battle after a dropped connection", async () => {
+    const { client1, client2, session1, battleId } = await setupBattle();
+
+    // Player1 loses the connection
+    await client1.close();
+
+    // Player2 is told about the disconnection
+    const disconnected = await waitForMessage(client2);
+    expect(disconnected.type).toBe(SERVER_MESSAGE_TYPE.Disconnect);
+    validateResponse(disconnected.payload, OPPONENT_DISCONNECTED_SCHEMA);
+    expect(disconnected.payload.player_id).toBe(session1.id);
+    expect(disconnected.payload.reconnected).toBe(false);
+
+    const resumed = new WSTestClient(WS_URL);
+    await resumed.connect();
+    await resumed.send(RECONNECT_REQUEST(session1.resume_token));
+
+    // Same player as before
+    const accepted = await waitForMessage(resumed);
+    expect(accepted.type).toBe(SERVER_MESSAGE_TYPE.AcceptConnection);
+    validateResponse(accepted.payload, CONNECT_SCHEMA);
+    expect(accepted.payload.id).toBe(session1.id);
+    expect(accepted.payload.username).toBe(session1.username);
+
+    // Followed by the current battle state
+    const status = await waitForMessage(resumed);
+    expect(status.type).toBe(SERVER_MESSAGE_TYPE.Status);
+    expect(status.payload.battle_id).toBe(battleId);
+    expect(status.payload.turn).toBe(1);
+    expect(status.payload.your_info.player_id).toBe(session1.id);
+
+    // Player2 is told about the return
+    const reconnected = await waitForMessage(client2);
+    expect(reconnected.type).toBe(SERVER_MESSAGE_TYPE.Disconnect);
+    expect(reconnected.payload.battle_id).toBe(battleId);
+    expect(reconnected.payload.reconnected).toBe(true);
+
+    // The battle goes on with the new connection
+    await resumed.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const ack = await waitForMessage(resumed);
+    expect(ack.type).toBe(SERVER_MESSAGE_TYPE.Attack);
+
+    await client2.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const [result1, result2] = await Promise.all([
+      waitForMessage(resumed),
+      waitForMessage(client2),
+    ]);
+    expect(result1.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+    expect(result2.type).toBe(SERVER_MESSAGE_TYPE.TurnResult);
+    validateResponse(result1.payload, TURN_RESULT_SCHEMA);
+
+    await Promise.all([resumed.close(), client2.close()]);
+  });
+
+  test("should reject an unknown resume token", async () => {
+    const client = new WSTestClient(WS_URL);
+    await client.connect();
+
+    await client.send(RECONNECT_REQUEST("not-a-token"));
+    const response = await waitForMessage(client);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(response.payload, ERROR_SCHEMA);
+    expect(response.payload.details.error).toContain("resume token");
+
+    await client.close();
+  });
+
+  test("should reject the resume token once the grace period is over", async () => {
+    const client = new WSTestClient(WS_URL);
+    await client.connect();
+
+    await client.send(CONNECT_REQUEST("persona 1", [1, 2, 3]));
+    const session = await waitForMessage(client);
+    await client.close();
+
+    await new Promise((resolve) => setTimeout(resolve, RECONNECT_GRACE_MS + 1000));
+
+    const resumed = new WSTestClient(WS_URL);
+    await resumed.connect();
+    await resumed.send(RECONNECT_REQUEST(session.payload.resume_token));
+    const response = await waitForMessage(resumed);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    expect(response.payload.details.error).toContain("expired");
+
+    await resumed.close();
+  }, RECONNECT_GRACE_MS + 5000);
+});
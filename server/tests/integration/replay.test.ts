@@ -0,0 +1,109 @@
+import { describe, test, expect } from "vitest";
+import axios from "axios";
+import {
+  API_URL,
+  CONNECT_REQUEST,
+  MATCH_REQUEST,
+  ATTACK_REQUEST,
+  SURRENDER_REQUEST,
+  REPLAY_SCHEMA,
+  ERROR_SCHEMA,
+  BODY_SLAM,
+  SERVER_MESSAGE_TYPE,
+  handleExpectedAxiosError,
+  handleUnexpectedAxiosError,
+  validateResponse,
+  waitForMessage,
+  WS_URL,
+  WSTestClient,
+} from "../helpers";
+
+// Helper function to play one turn of a battle and surrender the next one
+async function playShortBattle() {
+  const client1 = new WSTestClient(WS_URL);
+  const client2 = new WSTestClient(WS_URL);
+
+  await Promise.all([client1.connect(), client2.connect()]);
+
+  // Every pokemon on both teams knows Body Slam
+  await client1.send(CONNECT_REQUEST("Player1", [1, 2, 7]));
+  await client2.send(CONNECT_REQUEST("Player2", [2, 10, 7]));
+  await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+
+  await client1.send(MATCH_REQUEST());
+  await waitForMessage(client1); // Queue joined
+  await client2.send(MATCH_REQUEST());
+  const [match1] = await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+  const battleId = match1.payload.battle_id;
+
+  await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+  await waitForMessage(client1); // Attack queued
+  await client2.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+  await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+
+  await client1.send(SURRENDER_REQUEST(battleId));
+  const [ended] = await Promise.all([waitForMessage(client1), waitForMessage(client2)]);
+  expect(ended.type).toBe(SERVER_MESSAGE_TYPE.BattleEnded);
+
+  await Promise.all([client1.close(), client2.close()]);
+
+  return battleId;
+}
+
+describe("Battle Replays", () => {
+
+  test("should return every event of a finished battle by turn", async () => {
+    const battleId = await playShortBattle();
+
+    const response = await axios
+      .get(`${API_URL}/battle/${battleId}/replay`)
+      .catch(handleUnexpectedAxiosError);
+
+    expect(response.status).toBe(200);
+    validateResponse(response.data, REPLAY_SCHEMA);
+    expect(response.data.status).toBe("completed");
+
+    const [teams, turn1, turn2] = response.data.turns;
+
+    // Both teams of three
+    expect(teams.turn).toBe(0);
+    expect(teams.events).toHaveLength(6);
+    expect(teams.events.every((e) => e.type === "team")).toBe(true);
+
+    // Both chosen actions come before what they caused
+    expect(turn1.turn).toBe(1);
+    expect(turn1.events.slice(0, 2).map((e) => e.type)).toEqual(["action", "action"]);
+    expect(turn1.events.slice(0, 2).every((e) => e.move_id === BODY_SLAM)).toBe(true);
+    expect(turn1.events.some((e) => e.type === "attack" || e.type === "miss")).toBe(true);
+
+    expect(turn2.turn).toBe(2);
+    expect(turn2.events).toHaveLength(1);
+    expect(turn2.events[0].type).toBe("end");
+    expect(turn2.events[0].end_reason).toBe("surrender");
+
+    // Sequence numbers count every event of the battle in order
+    const sequences = response.data.turns.flatMap((t) => t.events.map((e) => e.sequence));
+    expect(sequences).toEqual(sequences.map((_, i) => i + 1));
+  });
+
+  test("should return 404 for an unknown battle", async () => {
+    const response = await axios
+      .get(`${API_URL}/battle/00000000-0000-0000-0000-000000000000/replay`)
+      .catch((err) => err);
+
+    handleExpectedAxiosError(response, (err) => {
+      expect(err.response.status).toBe(404);
+      validateResponse(err.response.data, ERROR_SCHEMA);
+    });
+  });
+
+  test("should return 400 for an invalid battle id", async () => {
+    const response = await axios
+      .get(`${API_URL}/battle/not-a-uuid/replay`)
+      .catch((err) => err);
+
+    handleExpectedAxiosError(response, (err) => {
+      expect(err.response.status).toBe(400);
+    });
+  });
+});
@@ -0,0 +1,137 @@
+import { describe, test, expect } from "vitest";
+import {
+  CONNECT_REQUEST,
+  CREATE_ROOM_REQUEST,
+  JOIN_ROOM_REQUEST,
+  CANCEL_ROOM_REQUEST,
+  ROOM_CREATED_SCHEMA,
+  ROOM_CLOSED_SCHEMA,
+  ROOM_TIMEOUT_MS,
+  MATCH_FOUND_SCHEMA,
+  ERROR_SCHEMA,
+  SERVER_MESSAGE_TYPE,
+  validateResponse,
+  waitForMessage,
+  WS_URL,
+  WSTestClient,
+} from "../helpers";
+
+// Helper function to connect two players
+async function connectPlayers() {
+  const host = new WSTestClient(WS_URL);
+  const guest = new WSTestClient(WS_URL);
+
+  await Promise.all([host.connect(), guest.connect()]);
+
+  await host.send(CONNECT_REQUEST("Host", [1, 2, 3]));
+  await guest.send(CONNECT_REQUEST("Guest", [4, 5, 6]));
+
+  await Promise.all([waitForMessage(host), waitForMessage(guest)]);
+
+  return { host, guest };
+}
+
+describe("Private Rooms", () => {
+
+  test("should create a room with a join code", async () => {
+    const { host, guest } = await connectPlayers();
+
+    await host.send(CREATE_ROOM_REQUEST());
+    const response = await waitForMessage(host);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.RoomCreated);
+    validateResponse(response.payload, ROOM_CREATED_SCHEMA);
+    expect(response.payload.expires_in_seconds).toBe(ROOM_TIMEOUT_MS / 1000);
+
+    await Promise.all([host.close(), guest.close()]);
+  });
+
+  test("should start a battle when a player joins with the code", async () => {
+    const { host, guest } = await connectPlayers();
+
+    await host.send(CREATE_ROOM_REQUEST());
+    const room = await waitForMessage(host);
+
+    await guest.send(JOIN_ROOM_REQUEST(room.payload.code));
+    const [match1, match2] = await Promise.all([
+      waitForMessage(host),
+      waitForMessage(guest),
+    ]);
+
+    expect(match1.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+    expect(match2.type).toBe(SERVER_MESSAGE_TYPE.MatchFound);
+    validateResponse(match1.payload, MATCH_FOUND_SCHEMA);
+    validateResponse(match2.payload, MATCH_FOUND_SCHEMA);
+    expect(match1.payload.battle_id).toBe(match2.payload.battle_id);
+    expect(match1.payload.opponent_info.username).toBe("Guest");
+    expect(match2.payload.opponent_info.username).toBe("Host");
+
+    await Promise.all([host.close(), guest.close()]);
+  });
+
+  test("should reject an unknown code", async () => {
+    const { host, guest } = await connectPlayers();
+
+    await guest.send(JOIN_ROOM_REQUEST("ZZZZZZ"));
+    const response = await waitForMessage(guest);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(response.payload, ERROR_SCHEMA);
+    expect(response.payload.details.error).toContain("room not found");
+
+    await Promise.all([host.close(), guest.close()]);
+  });
+
+  test("should not let the host join their own room", async () => {
+    const { host, guest } = await connectPlayers();
+
+    await host.send(CREATE_ROOM_REQUEST());
+    const room = await waitForMessage(host);
+
+    await host.send(JOIN_ROOM_REQUEST(room.payload.code));
+    const response = await waitForMessage(host);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    expect(response.payload.details.error).toContain("own room");
+
+    await Promise.all([host.close(), guest.close()]);
+  });
+
+  test("should close a cancelled room", async () => {
+    const { host, guest } = await connectPlayers();
+
+    await host.send(CREATE_ROOM_REQUEST());
+    const room = await waitForMessage(host);
+
+    await host.send(CANCEL_ROOM_REQUEST());
+    const closed = await waitForMessage(host);
+    expect(closed.type).toBe(SERVER_MESSAGE_TYPE.RoomClosed);
+    validateResponse(closed.payload, ROOM_CLOSED_SCHEMA);
+    expect(closed.payload.code).toBe(room.payload.code);
+    expect(closed.payload.reason).toBe("cancelled");
+
+    await guest.send(JOIN_ROOM_REQUEST(room.payload.code));
+    const response = await waitForMessage(guest);
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+
+    await Promise.all([host.close(), guest.close()]);
+  });
+
+  test("should close a room nobody joins in time", async () => {
+    const { host, guest } = await connectPlayers();
+
+    await host.send(CREATE_ROOM_REQUEST());
+    const room = await waitForMessage(host);
+
+    const closed = await waitForMessage(host, ROOM_TIMEOUT_MS + 2000);
+    expect(closed.type).toBe(SERVER_MESSAGE_TYPE.RoomClosed);
+    expect(closed.payload.code).toBe(room.payload.code);
+    expect(closed.payload.reason).toBe("expired");
+
+    await guest.send(JOIN_ROOM_REQUEST(room.payload.code));
+    const response = await waitForMessage(guest);
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+
+    await Promise.all([host.close(), guest.close()]);
+  });
+});
@@ -0,0 +1,167 @@
+import { describe, test, expect } from "vitest";
+import {
+  CONNECT_REQUEST,
+  MATCH_REQUEST,
+  ATTACK_REQUEST,
+  SURRENDER_REQUEST,
+  LIST_BATTLES_REQUEST,
+  SPECTATE_REQUEST,
+  STOP_SPECTATE_REQUEST,
+  BATTLE_LIST_SCHEMA,
+  SPECTATOR_STATE_SCHEMA,
+  SPECTATE_STOPPED_SCHEMA,
+  ERROR_SCHEMA,
+  BODY_SLAM,
+  SERVER_MESSAGE_TYPE,
+  validateResponse,
+  waitForMessage,
+  WS_URL,
+  WSTestClient,
+} from "../helpers";
+
+// Helper function to start a battle between two players and connect a third one to watch it
+async function setupBattleWithSpectator() {
+  const client1 = new WSTestClient(WS_URL);
+  const client2 = new WSTestClient(WS_URL);
+  const spectator = new WSTestClient(WS_URL);
+
+  await Promise.all([client1.connect(), client2.connect(), spectator.connect()]);
+
+  // Every pokemon on both teams knows Body Slam
+  await client1.send(CONNECT_REQUEST("Player1", [1, 2, 7]));
+  await client2.send(CONNECT_REQUEST("Player2", [2, 10, 7]));
+  await spectator.send(CONNECT_REQUEST("Spectator", [1, 2, 3]));
+
+  await Promise.all([
+    waitForMessage(client1),
+    waitForMessage(client2),
+    waitForMessage(spectator),
+  ]);
+
+  await client1.send(MATCH_REQUEST());
+  await waitForMessage(client1); // Queue joined
+
+  await client2.send(MATCH_REQUEST());
+  const [match1] = await Promise.all([
+    waitForMessage(client1),
+    waitForMessage(client2),
+  ]);
+
+  const battleId = match1.payload.battle_id;
+
+  return { client1, client2, spectator, battleId };
+}
+
+describe("Spectators", () => {
+
+  test("should list the battles being played", async () => {
+    const { client1, client2, spectator, battleId } = await setupBattleWithSpectator();
+
+    await spectator.send(LIST_BATTLES_REQUEST());
+    const response = await waitForMessage(spectator);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.BattleList);
+    validateResponse(response.payload, BATTLE_LIST_SCHEMA);
+
+    const battle = response.payload.battles.find((b) => b.battle_id === battleId);
+    expect(battle).toBeDefined();
+    expect(battle.player1).toBe("Player1");
+    expect(battle.player2).toBe("Player2");
+    expect(battle.spectators).toBe(0);
+
+    await Promise.all([client1.close(), client2.close(), spectator.close()]);
+  });
+
+  test("should send the state and every turn to the spectator", async () => {
+    const { client1, client2, spectator, battleId } = await setupBattleWithSpectator();
+
+    await spectator.send(SPECTATE_REQUEST(battleId));
+    const snapshot = await waitForMessage(spectator);
+
+    expect(snapshot.type).toBe(SERVER_MESSAGE_TYPE.SpectatorState);
+    validateResponse(snapshot.payload, SPECTATOR_STATE_SCHEMA);
+    expect(snapshot.payload.player1_info.username).toBe("Player1");
+    expect(snapshot.payload.player2_info.username).toBe("Player2");
+
+    await client1.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    await waitForMessage(client1); // Attack queued
+    await client2.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+
+    const update = await waitForMessage(spectator);
+    expect(update.type).toBe(SERVER_MESSAGE_TYPE.SpectatorState);
+    validateResponse(update.payload, SPECTATOR_STATE_SCHEMA);
+    expect(update.payload.turn).toBe(1);
+    expect(update.payload.events.length).toBeGreaterThan(0);
+
+    await Promise.all([client1.close(), client2.close(), spectator.close()]);
+  });
+
+  test("should not let the spectator act in the battle", async () => {
+    const { client1, client2, spectator, battleId } = await setupBattleWithSpectator();
+
+    await spectator.send(SPECTATE_REQUEST(battleId));
+    await waitForMessage(spectator); // Snapshot
+
+    await spectator.send(ATTACK_REQUEST(battleId, BODY_SLAM));
+    const response = await waitForMessage(spectator);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(response.payload, ERROR_SCHEMA);
+    expect(response.payload.details.error).toContain("not part of this battle");
+
+    await Promise.all([client1.close(), client2.close(), spectator.close()]);
+  });
+
+  test("should let the spectator know the battle ended", async () => {
+    const { client1, client2, spectator, battleId } = await setupBattleWithSpectator();
+
+    await spectator.send(SPECTATE_REQUEST(battleId));
+    await waitForMessage(spectator); // Snapshot
+
+    await client1.send(SURRENDER_REQUEST(battleId));
+    const update = await waitForMessage(spectator);
+
+    expect(update.type).toBe(SERVER_MESSAGE_TYPE.SpectatorState);
+    validateResponse(update.payload, SPECTATOR_STATE_SCHEMA);
+    expect(update.payload.battle_ended).toBe(true);
+    expect(update.payload.end_reason).toBe("surrender");
+    expect(update.payload.winner).toBe(update.payload.player2_info.player_id);
+
+    await Promise.all([client1.close(), client2.close(), spectator.close()]);
+  });
+
+  test("should stop sending updates once the spectator leaves", async () => {
+    const { client1, client2, spectator, battleId } = await setupBattleWithSpectator();
+
+    await spectator.send(SPECTATE_REQUEST(battleId));
+    await waitForMessage(spectator); // Snapshot
+
+    await spectator.send(STOP_SPECTATE_REQUEST(battleId));
+    const stopped = await waitForMessage(spectator);
+    expect(stopped.type).toBe(SERVER_MESSAGE_TYPE.SpectateStopped);
+    validateResponse(stopped.payload, SPECTATE_STOPPED_SCHEMA);
+
+    await client1.send(SURRENDER_REQUEST(battleId));
+    await waitForMessage(client1); // Battle ended
+
+    // The battle end was not sent, the next message is the answer to this one
+    await spectator.send(STOP_SPECTATE_REQUEST(battleId));
+    const response = await waitForMessage(spectator);
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+
+    await Promise.all([client1.close(), client2.close(), spectator.close()]);
+  });
+
+  test("should not let a player spectate their own battle", async () => {
+    const { client1, client2, spectator, battleId } = await setupBattleWithSpectator();
+
+    await client1.send(SPECTATE_REQUEST(battleId));
+    const response = await waitForMessage(client1);
+
+    expect(response.type).toBe(SERVER_MESSAGE_TYPE.Error);
+    validateResponse(response.payload, ERROR_SCHEMA);
+    expect(response.payload.details.error).toContain("your own battle");
+
+    await Promise.all([client1.close(), client2.close(), spectator.close()]);
+  });
+});